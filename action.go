@@ -1,12 +1,22 @@
 package githubactions
 
 import (
+	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"maps"
+	"math"
 	"math/big"
+	"net/http"
+	"net/url"
 	"os"
 	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/sethvargo/go-githubactions"
 	"go.starlark.net/starlark"
@@ -15,12 +25,226 @@ import (
 
 // Action wraps [githubactions.Action] for Starlark.
 type Action struct {
-	a *githubactions.Action
+	a       *githubactions.Action
+	environ func() []string
+	groups  atomic.Int32 // depth of open groups
+	fatal   atomic.Bool  // see FatalCalled
+	summary summaryBuffer
+
+	unrestrictedFS bool
+	httpClient     *http.Client
+
+	metadataM       sync.Mutex
+	metadata        *ActionMetadata
+	declared        *ActionMetadata // declared with declare_action
+	declarationOnly bool            // see DeclaredActionMetadata
+
+	deprecationWarnings bool                // see WithDeprecationWarnings
+	deprecationWarned   map[string]struct{} // declared names of reported deprecated inputs
+
+	masksM          sync.Mutex
+	masks           []string   // registered secret values, longest first
+	secretInputs    [][]string // lowercased words of name patterns of secret inputs
+	notSecretInputs [][]string // lowercased words of name suffixes excluded from secretInputs
+
+	cancelsM sync.Mutex
+	cancels  map[*starlark.Thread]context.CancelCauseFunc // for running builtins
+
+	checkRunsM sync.Mutex
+	checkRuns  []*checkRun // created by checks.create; see Finish
+
+	eventM      sync.Mutex
+	eventPath   string
+	event       starlark.Value // frozen; nil if not loaded
+	eventObject starlark.Value // event converted with toObject; nil if not converted
+}
+
+// Option configures an [Action].
+type Option func(*Action)
+
+// WithEnviron sets the function that lists all environment variables
+// in the "key=value" form, like [os.Environ] (the default).
+//
+// Values are still retrieved with [githubactions.Action.Getenv].
+func WithEnviron(environ func() []string) Option {
+	return func(a *Action) {
+		a.environ = environ
+	}
 }
 
 // New creates a new [Action].
-func New(a *githubactions.Action) *Action {
-	return &Action{a: a}
+func New(a *githubactions.Action, opts ...Option) *Action {
+	res := &Action{
+		a:       a,
+		environ: os.Environ,
+	}
+
+	for _, opt := range opts {
+		opt(res)
+	}
+
+	return res
+}
+
+// ContextKey is the [starlark.Thread] local key for the [context.Context]
+// used by builtins that perform I/O, such as HTTP requests.
+// If it is not set, [context.Background] is used.
+const ContextKey = "context"
+
+// threadContext returns the [context.Context] stored in the given thread.
+func threadContext(th *starlark.Thread) context.Context {
+	if ctx, ok := th.Local(ContextKey).(context.Context); ok && ctx != nil {
+		return ctx
+	}
+
+	return context.Background()
+}
+
+// Cancel cancels the given thread with [starlark.Thread.Cancel],
+// and also cancels builtins running in that thread
+// (for example, it kills subprocesses started by exec).
+//
+// Builtins do not notice threads canceled directly with [starlark.Thread.Cancel]
+// until they return, so embedders should cancel threads either with this method,
+// or with the [context.Context] stored in the thread under [ContextKey].
+//
+// It is safe to call Cancel from any goroutine.
+func (a *Action) Cancel(th *starlark.Thread, reason string) {
+	th.Cancel(reason)
+
+	a.cancelsM.Lock()
+	defer a.cancelsM.Unlock()
+
+	if cancel := a.cancels[th]; cancel != nil {
+		cancel(errors.New(reason))
+	}
+}
+
+// cancelableContext returns the thread context that is canceled by [Action.Cancel],
+// and a function that must be called when the builtin using it returns.
+func (a *Action) cancelableContext(th *starlark.Thread) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(threadContext(th))
+
+	a.cancelsM.Lock()
+	defer a.cancelsM.Unlock()
+
+	if a.cancels == nil {
+		a.cancels = make(map[*starlark.Thread]context.CancelCauseFunc)
+	}
+	a.cancels[th] = cancel
+
+	return ctx, func() {
+		a.cancelsM.Lock()
+		defer a.cancelsM.Unlock()
+
+		delete(a.cancels, th)
+		cancel(nil)
+	}
+}
+
+// minMaskVariantLength is the minimal length of a derived value (a line of multi-line secret value,
+// or a base64 fragment) that is masked separately.
+// Shorter values (like "{" in JSON credentials) are too common to be masked.
+const minMaskVariantLength = 4
+
+// base64Variants returns base64 forms of the given value for all three possible offsets
+// of the value in the encoded data (like in "user:" + value credentials).
+// Leading and trailing characters that depend on adjacent bytes are trimmed, like the runner does.
+func base64Variants(enc *base64.Encoding, value string) []string {
+	var res []string
+	for offset := range 3 {
+		b := append(make([]byte, offset), value...)
+		s := enc.EncodeToString(b)
+
+		// characters that encode only bits of the value
+		start := (offset*8 + 5) / 6
+		end := len(b) * 8 / 6
+		if end-start >= minMaskVariantLength {
+			res = append(res, s[start:end])
+		}
+	}
+
+	return res
+}
+
+// maskVariants returns values derived from the given secret value that should be masked too:
+// base64 (standard and URL-safe, at any offset), URL-encoded, and JSON-escaped forms,
+// and separate lines of multi-line values.
+func maskVariants(value string) []string {
+	res := base64Variants(base64.RawStdEncoding, value)
+	res = append(res, base64Variants(base64.RawURLEncoding, value)...)
+	res = append(res,
+		url.QueryEscape(value),
+		url.PathEscape(value),
+	)
+
+	var buf strings.Builder
+	e := json.NewEncoder(&buf)
+	e.SetEscapeHTML(false)
+	if err := e.Encode(value); err == nil {
+		s := strings.TrimSpace(buf.String())
+		res = append(res, s[1:len(s)-1])
+	}
+
+	if strings.Contains(value, "\n") {
+		for line := range strings.Lines(value) {
+			if line = strings.TrimSpace(line); len(line) >= minMaskVariantLength {
+				res = append(res, line)
+			}
+		}
+	}
+
+	// deduplicate, keeping the order
+	var variants []string
+	for _, v := range res {
+		if v != "" && v != value && !slices.Contains(variants, v) {
+			variants = append(variants, v)
+		}
+	}
+
+	return variants
+}
+
+// addMask registers the given value as secret, both locally and for the runner.
+// If derived is true, values returned by maskVariants are registered too.
+func (a *Action) addMask(value string, derived bool) {
+	if value == "" {
+		a.a.AddMask(value)
+		return
+	}
+
+	values := []string{value}
+	if derived {
+		values = append(values, maskVariants(value)...)
+	}
+
+	a.masksM.Lock()
+	defer a.masksM.Unlock()
+
+	for _, v := range values {
+		// do not repeat commands for inputs read several times
+		if slices.Contains(a.masks, v) {
+			continue
+		}
+
+		a.a.AddMask(v)
+		a.masks = append(a.masks, v)
+	}
+
+	// replace longer values first
+	slices.SortStableFunc(a.masks, func(a, b string) int { return len(b) - len(a) })
+}
+
+// mask replaces all registered secret values in s with "***".
+func (a *Action) mask(s string) string {
+	a.masksM.Lock()
+	defer a.masksM.Unlock()
+
+	for _, m := range a.masks {
+		s = strings.ReplaceAll(s, m, "***")
+	}
+
+	return s
 }
 
 // log logs a message using fmt.Printf-like function.
@@ -30,7 +254,92 @@ func (a *Action) log(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tu
 		return msg, err
 	}
 
-	logf("%s", msg)
+	logf("%s", a.mask(msg))
+	return msg, nil
+}
+
+// position is an optional positive line or column number argument.
+type position int
+
+// Unpack implements [starlark.Unpacker].
+func (p *position) Unpack(v starlark.Value) error {
+	i, err := starlark.AsInt32(v)
+	if err != nil {
+		return err
+	}
+
+	if i <= 0 {
+		return fmt.Errorf("got %d, want positive integer", i)
+	}
+
+	*p = position(i)
+	return nil
+}
+
+// annotate logs a message with optional annotation properties using fmt.Printf-like method.
+// See https://docs.github.com/en/actions/reference/workflows-and-actions/workflow-commands#about-workflow-commands.
+func (a *Action) annotate(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple, logf func(a *githubactions.Action, msg string, args ...any)) (string, error) {
+	var msg, file, title string
+	var line, endLine, col, endColumn position
+	if err := starlark.UnpackArgs(
+		fn.Name(), args, kwargs,
+		"msg", &msg,
+		"file??", &file,
+		"line??", &line,
+		"end_line??", &endLine,
+		"col??", &col,
+		"end_column??", &endColumn,
+		"title??", &title,
+	); err != nil {
+		return msg, err
+	}
+
+	if endLine != 0 {
+		if line == 0 {
+			return msg, fmt.Errorf("%s: end_line requires line", fn.Name())
+		}
+
+		if endLine < line {
+			return msg, fmt.Errorf("%s: end_line %d is less than line %d", fn.Name(), endLine, line)
+		}
+	}
+
+	if endColumn != 0 {
+		if col == 0 {
+			return msg, fmt.Errorf("%s: end_column requires col", fn.Name())
+		}
+
+		if endColumn < col {
+			return msg, fmt.Errorf("%s: end_column %d is less than col %d", fn.Name(), endColumn, col)
+		}
+	}
+
+	fields := make(map[string]string)
+	if file != "" {
+		fields["file"] = file
+	}
+	if line != 0 {
+		fields["line"] = strconv.Itoa(int(line))
+	}
+	if endLine != 0 {
+		fields["endLine"] = strconv.Itoa(int(endLine))
+	}
+	if col != 0 {
+		fields["col"] = strconv.Itoa(int(col))
+	}
+	if endColumn != 0 {
+		fields["endColumn"] = strconv.Itoa(int(endColumn))
+	}
+	if title != "" {
+		fields["title"] = a.mask(title)
+	}
+
+	ga := a.a
+	if len(fields) > 0 {
+		ga = ga.WithFieldsMap(fields)
+	}
+
+	logf(ga, "%s", a.mask(msg))
 	return msg, nil
 }
 
@@ -58,8 +367,9 @@ func (a *Action) Debug(th *starlark.Thread, fn *starlark.Builtin, args starlark.
 //
 // The caller is expected to format the message using string interpolation with % operator,
 // string.format method, or other means.
+// Optional file, line, end_line, col, end_column, and title keyword arguments create an annotation.
 func (a *Action) Notice(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
-	_, err := a.log(th, fn, args, kwargs, a.a.Noticef)
+	_, err := a.annotate(th, fn, args, kwargs, (*githubactions.Action).Noticef)
 	return starlark.None, err
 }
 
@@ -68,8 +378,9 @@ func (a *Action) Notice(th *starlark.Thread, fn *starlark.Builtin, args starlark
 //
 // The caller is expected to format the message using string interpolation with % operator,
 // string.format method, or other means.
+// Optional file, line, end_line, col, end_column, and title keyword arguments create an annotation.
 func (a *Action) Warning(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
-	_, err := a.log(th, fn, args, kwargs, a.a.Warningf)
+	_, err := a.annotate(th, fn, args, kwargs, (*githubactions.Action).Warningf)
 	return starlark.None, err
 }
 
@@ -78,8 +389,9 @@ func (a *Action) Warning(th *starlark.Thread, fn *starlark.Builtin, args starlar
 //
 // The caller is expected to format the message using string interpolation with % operator,
 // string.format method, or other means.
+// Optional file, line, end_line, col, end_column, and title keyword arguments create an annotation.
 func (a *Action) Error(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
-	_, err := a.log(th, fn, args, kwargs, a.a.Errorf)
+	_, err := a.annotate(th, fn, args, kwargs, (*githubactions.Action).Errorf)
 	return starlark.None, err
 }
 
@@ -87,16 +399,26 @@ func (a *Action) Error(th *starlark.Thread, fn *starlark.Builtin, args starlark.
 //
 // The caller is expected to format the message using string interpolation with % operator,
 // string.format method, or other means.
+// Optional file, line, end_line, col, end_column, and title keyword arguments create an annotation.
 func (a *Action) Fatal(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
-	msg, err := a.log(th, fn, args, kwargs, a.a.Errorf) // not Fatalf
+	msg, err := a.annotate(th, fn, args, kwargs, (*githubactions.Action).Errorf) // not Fatalf
 
 	if err == nil {
-		th.Cancel(msg)
+		a.fatal.Store(true)
+		a.Cancel(th, a.mask(msg))
 	}
 
 	return starlark.None, err
 }
 
+// FatalCalled reports whether the script called fatal.
+//
+// The error is already reported then, so the embedder should not report
+// the resulting cancellation error again, but still treat the run as failed.
+func (a *Action) FatalCalled() bool {
+	return a.fatal.Load()
+}
+
 // AddMatcher adds a new matcher with the given file path.
 func (a *Action) AddMatcher(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 	var path string
@@ -122,13 +444,20 @@ func (a *Action) RemoveMatcher(th *starlark.Thread, fn *starlark.Builtin, args s
 // AddMask adds a new field mask for the given value.
 // After called, future attempts to log the value will be replaced with "***" in log output.
 // See https://docs.github.com/en/actions/reference/workflows-and-actions/workflow-commands#masking-a-value-in-a-log.
+//
+// If derived is true, base64, URL-encoded, and JSON-escaped forms of the value,
+// and separate lines of multi-line value are masked too.
+//
+// Masked values are also replaced locally in messages, job summaries, and outputs written by this module,
+// and in texts (comments, check run outputs, and commit status descriptions) sent to the GitHub API.
 func (a *Action) AddMask(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 	var value string
-	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "value", &value); err != nil {
+	var derived bool
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "value", &value, "derived?", &derived); err != nil {
 		return nil, err
 	}
 
-	a.a.AddMask(value)
+	a.addMask(value, derived)
 	return starlark.None, nil
 }
 
@@ -141,7 +470,7 @@ func (a *Action) AddStepSummary(th *starlark.Thread, fn *starlark.Builtin, args
 		return nil, err
 	}
 
-	a.a.AddStepSummary(summary)
+	a.a.AddStepSummary(a.mask(summary))
 	return starlark.None, nil
 }
 
@@ -153,10 +482,28 @@ func (a *Action) Group(th *starlark.Thread, fn *starlark.Builtin, args starlark.
 		return nil, err
 	}
 
-	a.a.Group(title)
+	a.group(title)
 	return starlark.None, nil
 }
 
+// group starts a new group and tracks its depth.
+func (a *Action) group(title string) {
+	a.a.Group(title)
+	a.groups.Add(1)
+}
+
+// endGroup ends the current group and tracks its depth.
+func (a *Action) endGroup() {
+	a.a.EndGroup()
+
+	for {
+		n := a.groups.Load()
+		if n == 0 || a.groups.CompareAndSwap(n, n-1) {
+			return
+		}
+	}
+}
+
 // EndGroup ends the current group.
 // See https://docs.github.com/en/actions/reference/workflows-and-actions/workflow-commands#grouping-log-lines.
 func (a *Action) EndGroup(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
@@ -164,54 +511,460 @@ func (a *Action) EndGroup(th *starlark.Thread, fn *starlark.Builtin, args starla
 		return nil, err
 	}
 
-	a.a.EndGroup()
+	a.endGroup()
 	return starlark.None, nil
 }
 
+// WithGroup calls the given callable with the given arguments inside a new group,
+// and returns its result.
+// The group is always ended, even if the callable fails.
+// See https://docs.github.com/en/actions/reference/workflows-and-actions/workflow-commands#grouping-log-lines.
+func (a *Action) WithGroup(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if len(args) < 2 {
+		return nil, fmt.Errorf("%s: got %d positional arguments, want at least 2 (title, fn)", fn.Name(), len(args))
+	}
+
+	title, ok := starlark.AsString(args[0])
+	if !ok {
+		return nil, fmt.Errorf("%s: for parameter title: got %s, want string", fn.Name(), args[0].Type())
+	}
+
+	f, ok := args[1].(starlark.Callable)
+	if !ok {
+		return nil, fmt.Errorf("%s: for parameter fn: got %s, want callable", fn.Name(), args[1].Type())
+	}
+
+	a.group(title)
+	defer a.endGroup()
+
+	return starlark.Call(th, f, args[2:], kwargs)
+}
+
+// Finish should be called after the script ends.
+// It ends all groups that are still open and emits a warning about them.
+// It also completes check runs that were not finished by the script with the failure conclusion,
+// sending their remaining annotations, and emits a warning about each of them.
+func (a *Action) Finish() {
+	if n := a.groups.Load(); n > 0 {
+		for range n {
+			a.endGroup()
+		}
+
+		a.a.Warningf("%d group(s) were not ended by the script", n)
+	}
+
+	a.finishCheckRuns()
+}
+
+// inputEnv returns the environment variable name for the input with the given name.
+func inputEnv(name string) string {
+	return "INPUT_" + strings.ToUpper(strings.ReplaceAll(name, " ", "_"))
+}
+
+// defaultSecretInputs are the default name patterns of secret inputs for [WithSecretInputs].
+var defaultSecretInputs = []string{"token", "secret", "password", "key"}
+
+// defaultNotSecretInputs are name suffixes excluded from [defaultSecretInputs]:
+// well-known names of inputs with "key" that are not secrets.
+var defaultNotSecretInputs = []string{
+	"cache-key", "primary-key", "foreign-key", "sort-key", "partition-key",
+	"key-name", "key-path", "key-file",
+}
+
+// minSecretInputLength is the minimal length of a value of an input matching [WithSecretInputs] patterns
+// that is masked. Shorter values (like "1" or "no") are too common to be masked.
+const minSecretInputLength = 4
+
+// nameWords splits the input name or pattern into lowercased words separated by "-", "_", or space.
+func nameWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return r == '-' || r == '_' || r == ' '
+	})
+}
+
+// namePatterns splits patterns into words, skipping empty ones.
+func namePatterns(patterns []string) [][]string {
+	res := make([][]string, 0, len(patterns))
+	for _, p := range patterns {
+		if words := nameWords(p); len(words) > 0 {
+			res = append(res, words)
+		}
+	}
+
+	return res
+}
+
+// matchWords returns true if pattern words are found in name words in order,
+// with an optional plural "s" on the last one.
+// If suffix is true, they should be at the end of the name.
+func matchWords(words, pattern []string, suffix bool) bool {
+	start := 0
+	if suffix {
+		start = max(len(words)-len(pattern), 0)
+	}
+
+	last := len(pattern) - 1
+	for i := start; i+len(pattern) <= len(words); i++ {
+		w := words[i : i+len(pattern)]
+		if slices.Equal(w[:last], pattern[:last]) && (w[last] == pattern[last] || w[last] == pattern[last]+"s") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WithSecretInputs makes input builtins mask values of inputs with names matching any of the given patterns,
+// like with add_mask(value, derived=True).
+// Names and patterns are split into words separated by "-", "_", or space, and compared case-insensitively:
+// a pattern matches if its words are found in the name in order, with an optional plural "s" on the last one.
+// For example, "api-key" matches "OPENAI_API_KEY" and "api keys", but not "keyboard".
+//
+// If no patterns are given, "token", "secret", "password", and "key" are used,
+// except for names ending with well-known non-secret words like "cache-key", "primary-key", "sort-key",
+// or "key-path". So "ssh-key", "deploy-key", and "GPG_KEY" are masked, but "restore-cache-key" is not.
+//
+// Values shorter than 4 characters are not masked; a warning is emitted instead.
+// Independently of this option, get_input masks the value if secret=True is passed.
+func WithSecretInputs(patterns ...string) Option {
+	var exclude []string
+	if len(patterns) == 0 {
+		patterns = defaultSecretInputs
+		exclude = defaultNotSecretInputs
+	}
+
+	return func(a *Action) {
+		a.secretInputs = namePatterns(patterns)
+		a.notSecretInputs = namePatterns(exclude)
+	}
+}
+
+// isSecretInput returns true if the input with the given name matches patterns set by [WithSecretInputs].
+func (a *Action) isSecretInput(name string) bool {
+	words := nameWords(name)
+
+	if slices.ContainsFunc(a.notSecretInputs, func(p []string) bool { return matchWords(words, p, true) }) {
+		return false
+	}
+
+	return slices.ContainsFunc(a.secretInputs, func(p []string) bool { return matchWords(words, p, false) })
+}
+
+// input returns the value of the input with the given name and its environment variable name.
+// It returns an error if the input is required but not supplied,
+// or if it is not declared in the action metadata (see [WithActionMetadata]).
+//
+// Non-empty value is masked if secret is true, or if the input name matches [WithSecretInputs] patterns
+// and the value is long enough.
+func (a *Action) input(fn *starlark.Builtin, name string, required, trim, secret bool) (string, string, error) {
+	env := inputEnv(name)
+
+	v, err := a.metadataInput(fn, name, a.a.Getenv(env))
+	if err != nil {
+		return "", env, err
+	}
+
+	if required && v == "" {
+		return "", env, fmt.Errorf("%s: input %q (%s) is required and not supplied", fn.Name(), name, env)
+	}
+
+	if trim {
+		v = strings.TrimSpace(v)
+	}
+
+	switch {
+	case v == "":
+	case secret:
+		a.addMask(v, true)
+	case a.isSecretInput(name):
+		if len(v) < minSecretInputLength {
+			a.a.Warningf("Input %q looks like a secret, but its value is too short to be masked", name)
+			break
+		}
+
+		a.addMask(v, true)
+	}
+
+	return v, env, nil
+}
+
 // GetInput gets the input by the given name.
 // Returns the empty string if the input is not defined.
+//
+// If required is true, it fails if the input is not supplied.
+// If trim is true (the default), leading and trailing whitespace is removed.
+// If default is given, it is returned if the input is empty.
+// If secret is true, the value is masked like with add_mask(value, derived=True); see also [WithSecretInputs].
 func (a *Action) GetInput(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 	var name string
-	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "name", &name); err != nil {
+	var required bool
+	trim := true
+	var def starlark.Value
+	var secret bool
+	if err := starlark.UnpackArgs(
+		fn.Name(), args, kwargs,
+		"name", &name,
+		"required?", &required,
+		"trim?", &trim,
+		"default??", &def,
+		"secret?", &secret,
+	); err != nil {
+		return nil, err
+	}
+
+	v, _, err := a.input(fn, name, required && def == nil, trim, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	if v == "" && def != nil {
+		return def, nil
+	}
+
+	return starlark.String(v), nil
+}
+
+// GetBoolInput gets the boolean input by the given name.
+//
+// Only booleans of the YAML 1.2 "Core Schema" are accepted:
+// true, True, TRUE, false, False, FALSE.
+// If default is given, it is returned if the input is empty.
+func (a *Action) GetBoolInput(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var name string
+	var required bool
+	var def starlark.Value
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "name", &name, "required?", &required, "default??", &def); err != nil {
+		return nil, err
+	}
+
+	v, env, err := a.input(fn, name, required && def == nil, true, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if v == "" && def != nil {
+		return def, nil
+	}
+
+	switch v {
+	case "true", "True", "TRUE":
+		return starlark.True, nil
+	case "false", "False", "FALSE":
+		return starlark.False, nil
+	default:
+		return nil, fmt.Errorf(
+			"%s: input %q (%s) does not meet YAML 1.2 \"Core Schema\" specification: %q; "+
+				"supported values: true, True, TRUE, false, False, FALSE",
+			fn.Name(), name, env, v,
+		)
+	}
+}
+
+// GetIntInput gets the integer input by the given name.
+// If default is given, it is returned if the input is empty.
+func (a *Action) GetIntInput(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var name string
+	var required bool
+	var def starlark.Value
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "name", &name, "required?", &required, "default??", &def); err != nil {
+		return nil, err
+	}
+
+	v, env, err := a.input(fn, name, required && def == nil, true, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if v == "" && def != nil {
+		return def, nil
+	}
+
+	i, ok := new(big.Int).SetString(v, 10)
+	if !ok {
+		return nil, fmt.Errorf("%s: input %q (%s) is not an integer: %q", fn.Name(), name, env, v)
+	}
+
+	return starlark.MakeBigInt(i), nil
+}
+
+// GetFloatInput gets the floating-point input by the given name.
+// If default is given, it is returned if the input is empty.
+func (a *Action) GetFloatInput(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var name string
+	var required bool
+	var def starlark.Value
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "name", &name, "required?", &required, "default??", &def); err != nil {
+		return nil, err
+	}
+
+	v, env, err := a.input(fn, name, required && def == nil, true, false)
+	if err != nil {
 		return nil, err
 	}
 
-	return starlark.String(a.a.GetInput(name)), nil
+	if v == "" && def != nil {
+		return def, nil
+	}
+
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s: input %q (%s) is not a number: %q", fn.Name(), name, env, v)
+	}
+
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, fmt.Errorf("%s: input %q (%s) is not a finite number: %q", fn.Name(), name, env, v)
+	}
+
+	return starlark.Float(f), nil
+}
+
+// GetMultilineInput gets the input by the given name as a list of non-empty lines.
+//
+// If required is true, it fails if the input is not supplied.
+// If trim is true (the default), leading and trailing whitespace is removed from each line.
+// If default is given, it is returned if the input has no non-empty lines.
+func (a *Action) GetMultilineInput(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var name string
+	var required bool
+	trim := true
+	var def starlark.Value
+	if err := starlark.UnpackArgs(
+		fn.Name(), args, kwargs,
+		"name", &name,
+		"required?", &required,
+		"trim?", &trim,
+		"default??", &def,
+	); err != nil {
+		return nil, err
+	}
+
+	v, _, err := a.input(fn, name, required && def == nil, false, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []starlark.Value
+	for _, l := range strings.Split(v, "\n") {
+		if trim {
+			l = strings.TrimSpace(l)
+		}
+
+		if l != "" {
+			lines = append(lines, starlark.String(l))
+		}
+	}
+
+	if len(lines) == 0 && def != nil {
+		return def, nil
+	}
+
+	return starlark.NewList(lines), nil
 }
 
 // SetOutput sets an output parameter.
+// Values other than strings are encoded as JSON.
 // See https://docs.github.com/en/actions/reference/workflows-and-actions/workflow-commands#setting-an-output-parameter.
 func (a *Action) SetOutput(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
-	var name, value string
+	var name string
+	var value starlark.Value
 	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "name", &name, "value", &value); err != nil {
 		return nil, err
 	}
 
-	a.a.SetOutput(name, value)
+	if err := a.metadataOutput(fn, name); err != nil {
+		return nil, err
+	}
+
+	v, err := stringOrJSON(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	a.a.SetOutput(name, a.mask(v))
 	return starlark.None, nil
 }
 
 // SaveState saves state to be used in the "finally" post job entry point.
+// Values other than strings are encoded as JSON.
 // See https://docs.github.com/en/actions/reference/workflows-and-actions/workflow-commands#sending-values-to-the-pre-and-post-actions.
 func (a *Action) SaveState(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
-	var name, value string
+	var name string
+	var value starlark.Value
 	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "name", &name, "value", &value); err != nil {
 		return nil, err
 	}
 
-	a.a.SaveState(name, value)
+	v, err := stringOrJSON(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	a.a.SaveState(name, v)
 	return starlark.None, nil
 }
 
+// GetState gets the state saved by the previous step with [Action.SaveState].
+// Returns the empty string or the given default if the state is not defined.
+// See https://docs.github.com/en/actions/reference/workflows-and-actions/workflow-commands#sending-values-to-the-pre-and-post-actions.
+func (a *Action) GetState(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var name string
+	var def starlark.Value
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "name", &name, "default??", &def); err != nil {
+		return nil, err
+	}
+
+	v := a.a.Getenv("STATE_" + name)
+	if v == "" && def != nil {
+		return def, nil
+	}
+
+	return starlark.String(v), nil
+}
+
+// State returns all states saved by previous steps as a frozen dict.
+func (a *Action) State(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs); err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, kv := range a.environ() {
+		k, _, _ := strings.Cut(kv, "=")
+		if name, ok := strings.CutPrefix(k, "STATE_"); ok && name != "" {
+			names = append(names, name)
+		}
+	}
+
+	slices.Sort(names)
+	names = slices.Compact(names)
+
+	res := starlark.NewDict(len(names))
+	for _, name := range names {
+		if err := res.SetKey(starlark.String(name), starlark.String(a.a.Getenv("STATE_"+name))); err != nil {
+			return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+		}
+	}
+
+	res.Freeze()
+	return res, nil
+}
+
 // SetEnv sets an environment variable.
+// Values other than strings are encoded as JSON.
 // See https://docs.github.com/en/actions/reference/workflows-and-actions/workflow-commands#setting-an-environment-variable.
 func (a *Action) SetEnv(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
-	var name, value string
+	var name string
+	var value starlark.Value
 	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "name", &name, "value", &value); err != nil {
 		return nil, err
 	}
 
-	a.a.SetEnv(name, value)
+	v, err := stringOrJSON(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	a.a.SetEnv(name, v)
 	return starlark.None, nil
 }
 
@@ -228,20 +981,41 @@ func (a *Action) AddPath(th *starlark.Thread, fn *starlark.Builtin, args starlar
 }
 
 // Context returns the GitHub Actions Context as a Starlark struct.
+//
+// The event payload is decoded lazily on the first access to the event field,
+// and then cached until GITHUB_EVENT_PATH changes,
+// so calling this function repeatedly, or without using the event, is cheap.
+// The event field has type "event"; if typed_event is true, it is accessed like [*Object],
+// with attribute access; otherwise, like a dict.
 func (a *Action) Context(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
-	if err := starlark.UnpackArgs(fn.Name(), args, kwargs); err != nil {
+	var typedEvent bool
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "typed_event?", &typedEvent); err != nil {
 		return nil, err
 	}
 
-	ctx, err := a.a.Context()
+	// do not let go-githubactions read and decode the event; we do that once ourselves
+	eventPath := a.a.Getenv("GITHUB_EVENT_PATH")
+	ga := githubactions.New(githubactions.WithGetenv(func(key string) string {
+		if key == "GITHUB_EVENT_PATH" {
+			return ""
+		}
+
+		return a.a.Getenv(key)
+	}))
+
+	ctx, err := ga.Context()
 	if err != nil {
 		return nil, fmt.Errorf("Context: %w", err)
 	}
 
-	// do not use ctx.Event; decode ourselves to convert to Starlark values
-	event, err := readEvent(ctx.EventPath)
-	if err != nil {
-		return nil, fmt.Errorf("Context: %w", err)
+	event := starlark.Value(starlark.None)
+	if eventPath != "" {
+		// report a missing file early; the payload itself is decoded lazily
+		if _, err = os.Stat(eventPath); err != nil {
+			return nil, fmt.Errorf("Context: %w", err)
+		}
+
+		event = &lazyEvent{a: a, path: eventPath, object: typedEvent}
 	}
 
 	res := starlarkstruct.FromStringDict(starlark.String("context"), starlark.StringDict{
@@ -256,7 +1030,7 @@ func (a *Action) Context(th *starlark.Thread, fn *starlark.Builtin, args starlar
 		"env":               starlark.String(ctx.Env),
 		"event":             event,
 		"event_name":        starlark.String(ctx.EventName),
-		"event_path":        starlark.String(ctx.EventPath),
+		"event_path":        starlark.String(eventPath),
 		"graphql_url":       starlark.String(ctx.GraphqlURL),
 		"head_ref":          starlark.String(ctx.HeadRef),
 		"job":               starlark.String(ctx.Job),
@@ -283,6 +1057,42 @@ func (a *Action) Context(th *starlark.Thread, fn *starlark.Builtin, args starlar
 	return res, nil
 }
 
+// loadEvent returns the frozen event payload at the given path,
+// reading and decoding it only if it is not cached yet or the path changed.
+// If object is true, the payload is converted with [toObject].
+func (a *Action) loadEvent(path string, object bool) (starlark.Value, error) {
+	a.eventM.Lock()
+	defer a.eventM.Unlock()
+
+	if a.event == nil || a.eventPath != path {
+		event, err := readEvent(path)
+		if err != nil {
+			return nil, fmt.Errorf("Context: %w", err)
+		}
+
+		event.Freeze()
+
+		a.eventPath = path
+		a.event = event
+		a.eventObject = nil
+	}
+
+	if !object {
+		return a.event, nil
+	}
+
+	if a.eventObject == nil {
+		event, err := toObject(a.event)
+		if err != nil {
+			return nil, fmt.Errorf("Context: %w", err)
+		}
+
+		a.eventObject = event
+	}
+
+	return a.eventObject, nil
+}
+
 // readEvent reads and decodes the GitHub event JSON file at the given path.
 func readEvent(path string) (starlark.Value, error) {
 	if path == "" {
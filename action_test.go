@@ -2,7 +2,9 @@ package githubactions
 
 import (
 	"bytes"
+	"encoding/base64"
 	"io"
+	"math/big"
 	"os"
 	"strings"
 	"testing"
@@ -12,10 +14,27 @@ import (
 	"github.com/sethvargo/go-githubactions"
 	"go.starlark.net/starlark"
 	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
 )
 
 // setup prepares a Starlark thread and GitHub Actions module for testing.
-func setup(tb testing.TB, w io.Writer, getenv githubactions.GetenvFunc) (*starlark.Thread, *starlarkstruct.Module, githubactions.GetenvFunc) {
+func setup(tb testing.TB, w io.Writer, getenv githubactions.GetenvFunc, opts ...Option) (*starlark.Thread, *starlarkstruct.Module, githubactions.GetenvFunc) {
+	tb.Helper()
+
+	return setupGetenv(tb, w, getenv, true, opts...)
+}
+
+// setupEnv is like setup, but getenv provides the whole fake environment:
+// variables that are neither provided by it nor command files are empty.
+func setupEnv(tb testing.TB, w io.Writer, getenv githubactions.GetenvFunc, opts ...Option) (*starlark.Thread, *starlarkstruct.Module, githubactions.GetenvFunc) {
+	tb.Helper()
+
+	return setupGetenv(tb, w, getenv, false, opts...)
+}
+
+// setupGetenv implements setup and setupEnv.
+// If strict is true, unexpected environment variables fail the test.
+func setupGetenv(tb testing.TB, w io.Writer, getenv githubactions.GetenvFunc, strict bool, opts ...Option) (*starlark.Thread, *starlarkstruct.Module, githubactions.GetenvFunc) {
 	tb.Helper()
 
 	must.NotBeZerof(tb, w, "writer must not be nil")
@@ -41,7 +60,11 @@ func setup(tb testing.TB, w io.Writer, getenv githubactions.GetenvFunc) (*starla
 			}
 		}
 
-		fn := files[key]
+		fn, ok := files[key]
+		if !ok && !strict {
+			return ""
+		}
+
 		must.NotBeZero(tb, fn)
 		return fn
 	}
@@ -58,7 +81,7 @@ func setup(tb testing.TB, w io.Writer, getenv githubactions.GetenvFunc) (*starla
 		New(githubactions.New(
 			githubactions.WithWriter(w),
 			githubactions.WithGetenv(newGetenv),
-		)),
+		), opts...),
 	)
 	return th, m, newGetenv
 }
@@ -113,6 +136,103 @@ func TestError(t *testing.T) {
 	should.BeEqual(t, buf.String(), "::error::error message\n")
 }
 
+func TestAnnotation(t *testing.T) {
+	for name, tc := range map[string]struct {
+		fn       string
+		kwargs   []starlark.Tuple
+		expected string
+	}{
+		"NoticeFile": {
+			fn: "notice",
+			kwargs: []starlark.Tuple{
+				{starlark.String("file"), starlark.String("main.go")},
+			},
+			expected: "::notice file=main.go::message\n",
+		},
+		"WarningAll": {
+			fn: "warning",
+			kwargs: []starlark.Tuple{
+				{starlark.String("file"), starlark.String("cmd/main.go")},
+				{starlark.String("line"), starlark.MakeInt(10)},
+				{starlark.String("end_line"), starlark.MakeInt(12)},
+				{starlark.String("col"), starlark.MakeInt(3)},
+				{starlark.String("end_column"), starlark.MakeInt(5)},
+				{starlark.String("title"), starlark.String("Lint: unused, really")},
+			},
+			expected: "::warning col=3,endColumn=5,endLine=12,file=cmd/main.go,line=10,title=Lint%3A unused%2C really::message\n",
+		},
+		"ErrorLine": {
+			fn: "error",
+			kwargs: []starlark.Tuple{
+				{starlark.String("file"), starlark.String("main.go")},
+				{starlark.String("line"), starlark.MakeInt(1)},
+				{starlark.String("title"), starlark.None},
+			},
+			expected: "::error file=main.go,line=1::message\n",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			th, m, _ := setup(t, &buf, nil)
+
+			res, err := starlark.Call(th, m.Members[tc.fn], starlark.Tuple{starlark.String("message")}, tc.kwargs)
+			must.BeZero(t, err)
+			should.BeEqual(t, res, starlark.None)
+			should.BeEqual(t, buf.String(), tc.expected)
+		})
+	}
+}
+
+func TestAnnotationErrors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		kwargs   []starlark.Tuple
+		expected string
+	}{
+		"ZeroLine": {
+			kwargs: []starlark.Tuple{
+				{starlark.String("line"), starlark.MakeInt(0)},
+			},
+			expected: "warning: for parameter \"line\": got 0, want positive integer",
+		},
+		"NegativeCol": {
+			kwargs: []starlark.Tuple{
+				{starlark.String("col"), starlark.MakeInt(-1)},
+			},
+			expected: "warning: for parameter \"col\": got -1, want positive integer",
+		},
+		"EndLineWithoutLine": {
+			kwargs: []starlark.Tuple{
+				{starlark.String("end_line"), starlark.MakeInt(2)},
+			},
+			expected: "warning: end_line requires line",
+		},
+		"EndLineBeforeLine": {
+			kwargs: []starlark.Tuple{
+				{starlark.String("line"), starlark.MakeInt(5)},
+				{starlark.String("end_line"), starlark.MakeInt(4)},
+			},
+			expected: "warning: end_line 4 is less than line 5",
+		},
+		"EndColumnBeforeCol": {
+			kwargs: []starlark.Tuple{
+				{starlark.String("col"), starlark.MakeInt(5)},
+				{starlark.String("end_column"), starlark.MakeInt(4)},
+			},
+			expected: "warning: end_column 4 is less than col 5",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			th, m, _ := setup(t, &buf, nil)
+
+			_, err := starlark.Call(th, m.Members["warning"], starlark.Tuple{starlark.String("message")}, tc.kwargs)
+			must.NotBeZero(t, err)
+			should.BeEqual(t, err.Error(), tc.expected)
+			should.BeEqual(t, buf.String(), "")
+		})
+	}
+}
+
 func TestAddMatcher(t *testing.T) {
 	var buf bytes.Buffer
 	th, m, _ := setup(t, &buf, nil)
@@ -143,6 +263,79 @@ func TestAddMask(t *testing.T) {
 	should.BeEqual(t, buf.String(), "::add-mask::secret-value\n")
 }
 
+func TestAddMaskDerived(t *testing.T) {
+	var buf bytes.Buffer
+	th, m, getenv := setup(t, &buf, nil)
+
+	kwargs := []starlark.Tuple{{starlark.String("derived"), starlark.True}}
+	_, err := starlark.Call(th, m.Members["add_mask"], starlark.Tuple{starlark.String("p@ss/w\"rd\n{\nsecond line")}, kwargs)
+	must.BeZero(t, err)
+
+	// base64 (at three offsets) and URL-safe base64 are the same there;
+	// "%" is escaped as "%25" in workflow commands
+	expected := "::add-mask::p@ss/w\"rd%0A{%0Asecond line\n" +
+		"::add-mask::cEBzcy93InJkCnsKc2Vjb25kIGxpbm\n" +
+		"::add-mask::BAc3MvdyJyZAp7CnNlY29uZCBsaW5l\n" +
+		"::add-mask::wQHNzL3cicmQKewpzZWNvbmQgbGluZ\n" +
+		"::add-mask::p%2540ss%252Fw%2522rd%250A%257B%250Asecond+line\n" +
+		"::add-mask::p@ss%252Fw%2522rd%250A%257B%250Asecond%2520line\n" +
+		"::add-mask::p@ss/w\\\"rd\\n{\\nsecond line\n" +
+		"::add-mask::p@ss/w\"rd\n" +
+		"::add-mask::second line\n"
+	should.BeEqual(t, buf.String(), expected)
+	buf.Reset()
+
+	for _, name := range []string{"log", "debug", "warning"} {
+		_, err = starlark.Call(th, m.Members[name], starlark.Tuple{starlark.String("token cEBzcy93InJkCnsKc2Vjb25kIGxpbmU=")}, nil)
+		must.BeZero(t, err)
+	}
+
+	_, err = starlark.Call(th, m.Members["add_step_summary"], starlark.Tuple{starlark.String("first: p@ss/w\"rd")}, nil)
+	must.BeZero(t, err)
+
+	_, err = starlark.Call(th, m.Members["set_output"], starlark.Tuple{starlark.String("url"), starlark.String("https://example.com/?p=p%40ss%2Fw%22rd%0A%7B%0Asecond+line")}, nil)
+	must.BeZero(t, err)
+
+	// the last base64 character depends on the following data
+	expected = "token ***U=\n" +
+		"::debug::token ***U=\n" +
+		"::warning::token ***U=\n"
+	should.BeEqual(t, buf.String(), expected)
+
+	b, err := os.ReadFile(getenv("GITHUB_STEP_SUMMARY"))
+	must.BeZero(t, err)
+	should.BeEqual(t, string(b), "first: ***\n")
+
+	commands, err := ReadFileCommands(getenv("GITHUB_OUTPUT"))
+	must.BeZero(t, err)
+	should.BeEqual(t, commands, []FileCommand{{Name: "url", Value: "https://example.com/?p=***"}})
+}
+
+func TestAddMaskBase64(t *testing.T) {
+	const token = "ghp_R2d2C3po4BB8xyz"
+
+	for prefix, expected := range map[string]string{
+		"x:":   "Basic eDp***\n",
+		"ab:":  "Basic YWI6***g==\n",
+		"abc:": "Basic YWJjOm***o=\n",
+	} {
+		t.Run(prefix, func(t *testing.T) {
+			var buf bytes.Buffer
+			th, m, _ := setup(t, &buf, nil)
+
+			kwargs := []starlark.Tuple{{starlark.String("derived"), starlark.True}}
+			_, err := starlark.Call(th, m.Members["add_mask"], starlark.Tuple{starlark.String(token)}, kwargs)
+			must.BeZero(t, err)
+			buf.Reset()
+
+			msg := "Basic " + base64.StdEncoding.EncodeToString([]byte(prefix+token))
+			_, err = starlark.Call(th, m.Members["log"], starlark.Tuple{starlark.String(msg)}, nil)
+			must.BeZero(t, err)
+			should.BeEqual(t, buf.String(), expected)
+		})
+	}
+}
+
 func TestAddStepSummary(t *testing.T) {
 	var buf bytes.Buffer
 	th, m, getenv := setup(t, &buf, nil)
@@ -177,6 +370,84 @@ func TestEndGroup(t *testing.T) {
 	should.BeEqual(t, buf.String(), "::endgroup::\n")
 }
 
+func TestWithGroup(t *testing.T) {
+	var buf bytes.Buffer
+	th, m, _ := setup(t, &buf, nil)
+
+	script := `
+def ok(a, b = 0):
+    log("inside")
+    return a + b
+
+def bad():
+    log("inside")
+    fail("bad")
+
+def nested():
+    return with_group("inner", ok, 1)
+`
+	globals, err := starlark.ExecFileOptions(&syntax.FileOptions{}, th, t.Name(), script, m.Members)
+	must.BeZero(t, err)
+
+	res, err := starlark.Call(th, m.Members["with_group"], starlark.Tuple{starlark.String("title"), globals["ok"], starlark.MakeInt(1)}, []starlark.Tuple{
+		{starlark.String("b"), starlark.MakeInt(2)},
+	})
+	must.BeZero(t, err)
+	should.BeEqual(t, res, starlark.MakeInt(3))
+	should.BeEqual(t, buf.String(), "::group::title\ninside\n::endgroup::\n")
+
+	buf.Reset()
+	_, err = starlark.Call(th, m.Members["with_group"], starlark.Tuple{starlark.String("title"), globals["bad"]}, nil)
+	must.NotBeZero(t, err)
+	should.BeEqual(t, err.Error(), "fail: bad")
+	should.BeEqual(t, buf.String(), "::group::title\ninside\n::endgroup::\n")
+
+	buf.Reset()
+	res, err = starlark.Call(th, m.Members["with_group"], starlark.Tuple{starlark.String("outer"), globals["nested"]}, nil)
+	must.BeZero(t, err)
+	should.BeEqual(t, res, starlark.MakeInt(1))
+	should.BeEqual(t, buf.String(), "::group::outer\n::group::inner\ninside\n::endgroup::\n::endgroup::\n")
+
+	buf.Reset()
+	_, err = starlark.Call(th, m.Members["with_group"], starlark.Tuple{starlark.String("title")}, nil)
+	must.NotBeZero(t, err)
+	should.BeEqual(t, err.Error(), "with_group: got 1 positional arguments, want at least 2 (title, fn)")
+	should.BeEqual(t, buf.String(), "")
+}
+
+func TestFinish(t *testing.T) {
+	var buf bytes.Buffer
+	a := New(githubactions.New(githubactions.WithWriter(&buf)))
+	m := NewModule(t.Name(), a)
+	th := &starlark.Thread{Name: t.Name()}
+
+	a.Finish()
+	should.BeEqual(t, buf.String(), "")
+
+	for _, title := range []string{"first", "second", "third"} {
+		_, err := starlark.Call(th, m.Members["group"], starlark.Tuple{starlark.String(title)}, nil)
+		must.BeZero(t, err)
+	}
+
+	_, err := starlark.Call(th, m.Members["end_group"], nil, nil)
+	must.BeZero(t, err)
+
+	buf.Reset()
+	a.Finish()
+	should.BeEqual(t, buf.String(), "::endgroup::\n::endgroup::\n::warning::2 group(s) were not ended by the script\n")
+
+	buf.Reset()
+	a.Finish()
+	should.BeEqual(t, buf.String(), "")
+}
+
+// inputGetenv returns a getenv function for the given input environment variables.
+func inputGetenv(inputs map[string]string) func(key string) string {
+	return func(key string) string {
+		return inputs[key]
+	}
+}
+
 func TestGetInput(t *testing.T) {
 	var buf bytes.Buffer
 	th, m, _ := setup(t, &buf, func(key string) string {
@@ -190,6 +461,388 @@ func TestGetInput(t *testing.T) {
 	should.BeEqual(t, buf.String(), "")
 }
 
+func TestGetInputOptions(t *testing.T) {
+	getenv := inputGetenv(map[string]string{
+		"INPUT_MY_INPUT": "  test value\n",
+		"INPUT_EMPTY":    "",
+	})
+
+	for name, tc := range map[string]struct {
+		name     string
+		kwargs   []starlark.Tuple
+		expected starlark.Value
+		err      string
+	}{
+		"Trim": {
+			name:     "my input",
+			expected: starlark.String("test value"),
+		},
+		"NoTrim": {
+			name:     "my_input",
+			kwargs:   []starlark.Tuple{{starlark.String("trim"), starlark.False}},
+			expected: starlark.String("  test value\n"),
+		},
+		"Missing": {
+			name:     "empty",
+			expected: starlark.String(""),
+		},
+		"Default": {
+			name:     "empty",
+			kwargs:   []starlark.Tuple{{starlark.String("default"), starlark.String("default value")}},
+			expected: starlark.String("default value"),
+		},
+		"RequiredDefault": {
+			name: "empty",
+			kwargs: []starlark.Tuple{
+				{starlark.String("required"), starlark.True},
+				{starlark.String("default"), starlark.String("default value")},
+			},
+			expected: starlark.String("default value"),
+		},
+		"Required": {
+			name:   "empty",
+			kwargs: []starlark.Tuple{{starlark.String("required"), starlark.True}},
+			err:    `get_input: input "empty" (INPUT_EMPTY) is required and not supplied`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			th, m, _ := setupEnv(t, &buf, getenv)
+
+			res, err := starlark.Call(th, m.Members["get_input"], starlark.Tuple{starlark.String(tc.name)}, tc.kwargs)
+			if tc.err != "" {
+				must.NotBeZero(t, err)
+				should.BeEqual(t, err.Error(), tc.err)
+				return
+			}
+
+			must.BeZero(t, err)
+			should.BeEqual(t, res, tc.expected)
+		})
+	}
+}
+
+func TestGetBoolInput(t *testing.T) {
+	for name, tc := range map[string]struct {
+		value    string
+		kwargs   []starlark.Tuple
+		expected starlark.Value
+		err      string
+	}{
+		"true":  {value: "true", expected: starlark.True},
+		"True":  {value: " True ", expected: starlark.True},
+		"TRUE":  {value: "TRUE", expected: starlark.True},
+		"false": {value: "false", expected: starlark.False},
+		"False": {value: "False", expected: starlark.False},
+		"FALSE": {value: "FALSE", expected: starlark.False},
+		"Default": {
+			kwargs:   []starlark.Tuple{{starlark.String("default"), starlark.True}},
+			expected: starlark.True,
+		},
+		"yes": {
+			value: "yes",
+			err: `get_bool_input: input "dry_run" (INPUT_DRY_RUN) does not meet YAML 1.2 "Core Schema" specification: "yes"; ` +
+				`supported values: true, True, TRUE, false, False, FALSE`,
+		},
+		"Empty": {
+			err: `get_bool_input: input "dry_run" (INPUT_DRY_RUN) does not meet YAML 1.2 "Core Schema" specification: ""; ` +
+				`supported values: true, True, TRUE, false, False, FALSE`,
+		},
+		"Required": {
+			kwargs: []starlark.Tuple{{starlark.String("required"), starlark.True}},
+			err:    `get_bool_input: input "dry_run" (INPUT_DRY_RUN) is required and not supplied`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			th, m, _ := setupEnv(t, &buf, inputGetenv(map[string]string{"INPUT_DRY_RUN": tc.value}))
+
+			res, err := starlark.Call(th, m.Members["get_bool_input"], starlark.Tuple{starlark.String("dry_run")}, tc.kwargs)
+			if tc.err != "" {
+				must.NotBeZero(t, err)
+				should.BeEqual(t, err.Error(), tc.err)
+				return
+			}
+
+			must.BeZero(t, err)
+			should.BeEqual(t, res, tc.expected)
+		})
+	}
+}
+
+func TestGetIntInput(t *testing.T) {
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+
+	for name, tc := range map[string]struct {
+		value    string
+		kwargs   []starlark.Tuple
+		expected starlark.Value
+		err      string
+	}{
+		"Small":    {value: "42", expected: starlark.MakeInt(42)},
+		"Negative": {value: "-1", expected: starlark.MakeInt(-1)},
+		"Big":      {value: "123456789012345678901234567890", expected: starlark.MakeBigInt(huge)},
+		"Default": {
+			kwargs:   []starlark.Tuple{{starlark.String("default"), starlark.MakeInt(3)}},
+			expected: starlark.MakeInt(3),
+		},
+		"Float": {
+			value: "4.2",
+			err:   `get_int_input: input "retries" (INPUT_RETRIES) is not an integer: "4.2"`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			th, m, _ := setupEnv(t, &buf, inputGetenv(map[string]string{"INPUT_RETRIES": tc.value}))
+
+			res, err := starlark.Call(th, m.Members["get_int_input"], starlark.Tuple{starlark.String("retries")}, tc.kwargs)
+			if tc.err != "" {
+				must.NotBeZero(t, err)
+				should.BeEqual(t, err.Error(), tc.err)
+				return
+			}
+
+			must.BeZero(t, err)
+			should.BeEqual(t, res.String(), tc.expected.String())
+		})
+	}
+}
+
+func TestGetFloatInput(t *testing.T) {
+	for name, tc := range map[string]struct {
+		value    string
+		expected starlark.Value
+		err      string
+	}{
+		"Float": {value: "0.5", expected: starlark.Float(0.5)},
+		"Int":   {value: "2", expected: starlark.Float(2)},
+		"Text": {
+			value: "half",
+			err:   `get_float_input: input "ratio" (INPUT_RATIO) is not a number: "half"`,
+		},
+		"NaN": {
+			value: "NaN",
+			err:   `get_float_input: input "ratio" (INPUT_RATIO) is not a finite number: "NaN"`,
+		},
+		"Inf": {
+			value: "-Inf",
+			err:   `get_float_input: input "ratio" (INPUT_RATIO) is not a finite number: "-Inf"`,
+		},
+		"Overflow": {
+			value: "1e400",
+			err:   `get_float_input: input "ratio" (INPUT_RATIO) is not a number: "1e400"`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			th, m, _ := setupEnv(t, &buf, inputGetenv(map[string]string{"INPUT_RATIO": tc.value}))
+
+			res, err := starlark.Call(th, m.Members["get_float_input"], starlark.Tuple{starlark.String("ratio")}, nil)
+			if tc.err != "" {
+				must.NotBeZero(t, err)
+				should.BeEqual(t, err.Error(), tc.err)
+				return
+			}
+
+			must.BeZero(t, err)
+			should.BeEqual(t, res, tc.expected)
+		})
+	}
+}
+
+func TestGetMultilineInput(t *testing.T) {
+	var buf bytes.Buffer
+	th, m, _ := setupEnv(t, &buf, inputGetenv(map[string]string{"INPUT_FILES": " a.go\n\n  b.go  \nc.go\n"}))
+
+	res, err := starlark.Call(th, m.Members["get_multiline_input"], starlark.Tuple{starlark.String("files")}, nil)
+	must.BeZero(t, err)
+	should.BeEqual(t, res.String(), `["a.go", "b.go", "c.go"]`)
+
+	kwargs := []starlark.Tuple{{starlark.String("trim"), starlark.False}}
+	res, err = starlark.Call(th, m.Members["get_multiline_input"], starlark.Tuple{starlark.String("files")}, kwargs)
+	must.BeZero(t, err)
+	should.BeEqual(t, res.String(), `[" a.go", "  b.go  ", "c.go"]`)
+
+	kwargs = []starlark.Tuple{{starlark.String("required"), starlark.True}}
+	_, err = starlark.Call(th, m.Members["get_multiline_input"], starlark.Tuple{starlark.String("missing")}, kwargs)
+	must.NotBeZero(t, err)
+	should.BeEqual(t, err.Error(), `get_multiline_input: input "missing" (INPUT_MISSING) is required and not supplied`)
+
+	kwargs = []starlark.Tuple{
+		{starlark.String("required"), starlark.True},
+		{starlark.String("default"), starlark.NewList([]starlark.Value{starlark.String("*.go")})},
+	}
+	res, err = starlark.Call(th, m.Members["get_multiline_input"], starlark.Tuple{starlark.String("missing")}, kwargs)
+	must.BeZero(t, err)
+	should.BeEqual(t, res.String(), `["*.go"]`)
+}
+
+func TestSecretInputs(t *testing.T) {
+	getenv := inputGetenv(map[string]string{
+		"INPUT_GITHUB-TOKEN":    "ghp_123456",
+		"INPUT_API_KEY":         " k3y-1234 ",
+		"INPUT_ACCESS-TOKEN":    "no",
+		"INPUT_PASSWORDS":       "first-password\nsecond-password",
+		"INPUT_USER":            "alice",
+		"INPUT_PIN":             "1234",
+		"INPUT_SSH-KEY":         "ssh-ed25519",
+		"INPUT_DEPLOY-KEY":      "deploy-key-1",
+		"INPUT_SIGNING-KEY":     "signing-key-1",
+		"INPUT_GPG_KEY":         "gpg-key-1",
+		"INPUT_KEY":             "plain-key-1",
+		"INPUT_CACHE-KEY-TOKEN": "cache-token",
+		"INPUT_CACHE-KEY":       "linux-go-1234",
+		"INPUT_PRIMARY-KEY":     "id-1234",
+		"INPUT_KEYBOARD-LAYOUT": "dvorak",
+		"INPUT_SORT_KEY":        "created-at",
+		"INPUT_TOKENIZER":       "whitespace",
+
+		"INPUT_RESTORE-CACHE-KEYS": "linux-go-",
+		"INPUT_SSH-KEY-PATH":       "~/.ssh/id_ed25519",
+	})
+
+	for name, tc := range map[string]struct {
+		opts     []Option
+		input    string
+		kwargs   []starlark.Tuple
+		builtin  string
+		expected string
+	}{
+		"Token": {
+			opts:     []Option{WithSecretInputs()},
+			input:    "github-token",
+			expected: "::add-mask::ghp_123456\n::add-mask::Z2hwXzEyMzQ1N\n::add-mask::docF8xMjM0NT\n::add-mask::naHBfMTIzNDU2\n",
+		},
+		"Key": {
+			opts:     []Option{WithSecretInputs()},
+			input:    "API_KEY",
+			expected: "::add-mask::k3y-1234\n::add-mask::azN5LTEyMz\n::add-mask::szeS0xMjM0\n::add-mask::rM3ktMTIzN\n",
+		},
+		"SSHKey": {
+			opts:     []Option{WithSecretInputs()},
+			input:    "ssh-key",
+			expected: "::add-mask::ssh-ed25519\n::add-mask::c3NoLWVkMjU1MT\n::add-mask::NzaC1lZDI1NTE5\n::add-mask::zc2gtZWQyNTUxO\n",
+		},
+		"DeployKey": {
+			opts:     []Option{WithSecretInputs()},
+			input:    "deploy-key",
+			expected: "::add-mask::deploy-key-1\n::add-mask::ZGVwbG95LWtleS0x\n::add-mask::RlcGxveS1rZXktM\n::add-mask::kZXBsb3kta2V5LT\n",
+		},
+		"SigningKey": {
+			opts:     []Option{WithSecretInputs()},
+			input:    "signing-key",
+			expected: "::add-mask::signing-key-1\n::add-mask::c2lnbmluZy1rZXktM\n::add-mask::NpZ25pbmcta2V5LT\n::add-mask::zaWduaW5nLWtleS0x\n",
+		},
+		"GPGKey": {
+			opts:     []Option{WithSecretInputs()},
+			input:    "gpg_key",
+			expected: "::add-mask::gpg-key-1\n::add-mask::Z3BnLWtleS0x\n::add-mask::dwZy1rZXktM\n::add-mask::ncGcta2V5LT\n",
+		},
+		"PlainKey": {
+			opts:     []Option{WithSecretInputs()},
+			input:    "key",
+			expected: "::add-mask::plain-key-1\n::add-mask::cGxhaW4ta2V5LT\n::add-mask::BsYWluLWtleS0x\n::add-mask::wbGFpbi1rZXktM\n",
+		},
+		"CacheKeyToken": {
+			opts:     []Option{WithSecretInputs()},
+			input:    "cache-key-token",
+			expected: "::add-mask::cache-token\n::add-mask::Y2FjaGUtdG9rZW\n::add-mask::NhY2hlLXRva2Vu\n::add-mask::jYWNoZS10b2tlb\n",
+		},
+		"Short": {
+			opts:  []Option{WithSecretInputs()},
+			input: "access-token",
+			expected: "::warning::Input \"access-token\" looks like a secret, but its value is too short to be masked\n" +
+				"::warning::Input \"access-token\" looks like a secret, but its value is too short to be masked\n",
+		},
+		"CacheKey": {
+			opts:  []Option{WithSecretInputs()},
+			input: "cache-key",
+		},
+		"PrimaryKey": {
+			opts:  []Option{WithSecretInputs()},
+			input: "primary-key",
+		},
+		"KeyboardLayout": {
+			opts:  []Option{WithSecretInputs()},
+			input: "keyboard-layout",
+		},
+		"SortKey": {
+			opts:  []Option{WithSecretInputs()},
+			input: "sort_key",
+		},
+		"RestoreCacheKeys": {
+			opts:  []Option{WithSecretInputs()},
+			input: "restore-cache-keys",
+		},
+		"SSHKeyPath": {
+			opts:  []Option{WithSecretInputs()},
+			input: "ssh-key-path",
+		},
+		"PatternsCacheKey": {
+			opts:     []Option{WithSecretInputs("cache-key")},
+			input:    "cache-key",
+			expected: "::add-mask::linux-go-1234\n::add-mask::bGludXgtZ28tMTIzN\n::add-mask::xpbnV4LWdvLTEyMz\n::add-mask::saW51eC1nby0xMjM0\n",
+		},
+		"Tokenizer": {
+			opts:  []Option{WithSecretInputs()},
+			input: "tokenizer",
+		},
+		"Multiline": {
+			opts:    []Option{WithSecretInputs()},
+			input:   "passwords",
+			builtin: "get_multiline_input",
+			expected: "::add-mask::first-password%0Asecond-password\n" +
+				"::add-mask::Zmlyc3QtcGFzc3dvcmQKc2Vjb25kLXBhc3N3b3Jk\n" +
+				"::add-mask::ZpcnN0LXBhc3N3b3JkCnNlY29uZC1wYXNzd29yZ\n" +
+				"::add-mask::maXJzdC1wYXNzd29yZApzZWNvbmQtcGFzc3dvcm\n" +
+				"::add-mask::first-password%250Asecond-password\n" +
+				"::add-mask::first-password\\nsecond-password\n" +
+				"::add-mask::first-password\n" +
+				"::add-mask::second-password\n",
+		},
+		"NotSecret": {
+			opts:  []Option{WithSecretInputs()},
+			input: "user",
+		},
+		"Disabled": {
+			input: "github-token",
+		},
+		"Patterns": {
+			opts:     []Option{WithSecretInputs("PIN")},
+			input:    "pin",
+			builtin:  "get_int_input",
+			expected: "::add-mask::1234\n::add-mask::MTIzN\n::add-mask::EyMz\n::add-mask::xMjM0\n",
+		},
+		"PatternsToken": {
+			opts:  []Option{WithSecretInputs("PIN")},
+			input: "github-token",
+		},
+		"Explicit": {
+			input:    "user",
+			kwargs:   []starlark.Tuple{{starlark.String("secret"), starlark.True}},
+			expected: "::add-mask::alice\n::add-mask::YWxpY2\n::add-mask::FsaWNl\n::add-mask::hbGljZ\n",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			th, m, _ := setupEnv(t, &buf, getenv, tc.opts...)
+
+			builtin := tc.builtin
+			if builtin == "" {
+				builtin = "get_input"
+			}
+
+			// masks are added only once
+			for range 2 {
+				_, err := starlark.Call(th, m.Members[builtin], starlark.Tuple{starlark.String(tc.input)}, tc.kwargs)
+				must.BeZero(t, err)
+			}
+
+			should.BeEqual(t, buf.String(), tc.expected)
+		})
+	}
+}
+
 func TestSetOutput(t *testing.T) {
 	var buf bytes.Buffer
 	th, m, getenv := setup(t, &buf, nil)
@@ -204,6 +857,28 @@ func TestSetOutput(t *testing.T) {
 	should.BeEqual(t, string(b), "my_output<<_GitHubActionsFileCommandDelimeter_\noutput value\n_GitHubActionsFileCommandDelimeter_\n")
 }
 
+func TestSetOutputJSON(t *testing.T) {
+	var buf bytes.Buffer
+	th, m, getenv := setup(t, &buf, nil)
+
+	value := starlark.NewDict(2)
+	must.BeZero(t, value.SetKey(starlark.String("files"), starlark.NewList([]starlark.Value{starlark.String("a.go")})))
+	must.BeZero(t, value.SetKey(starlark.String("count"), starlark.MakeInt(1)))
+
+	res, err := starlark.Call(th, m.Members["set_output"], starlark.Tuple{starlark.String("my_output"), value}, nil)
+	must.BeZero(t, err)
+	should.BeEqual(t, res, starlark.None)
+	should.BeEqual(t, buf.String(), "")
+
+	_, err = starlark.Call(th, m.Members["set_output"], starlark.Tuple{starlark.String("bad_output"), m.Members["log"]}, nil)
+	must.NotBeZero(t, err)
+	should.BeEqual(t, err.Error(), "set_output: starlarkToJSON: unsupported type builtin_function_or_method")
+
+	b, err := os.ReadFile(getenv("GITHUB_OUTPUT"))
+	must.BeZero(t, err)
+	should.BeEqual(t, string(b), "my_output<<_GitHubActionsFileCommandDelimeter_\n{\"count\":1,\"files\":[\"a.go\"]}\n_GitHubActionsFileCommandDelimeter_\n")
+}
+
 func TestSaveState(t *testing.T) {
 	var buf bytes.Buffer
 	th, m, getenv := setup(t, &buf, nil)
@@ -218,6 +893,64 @@ func TestSaveState(t *testing.T) {
 	should.BeEqual(t, string(b), "my_state<<_GitHubActionsFileCommandDelimeter_\nstate value\n_GitHubActionsFileCommandDelimeter_\n")
 }
 
+func TestState(t *testing.T) {
+	// main step
+	var buf bytes.Buffer
+	th, m, getenv := setup(t, &buf, nil)
+
+	for name, value := range map[string]string{
+		"pid":   "1234",
+		"lines": "first\nsecond",
+	} {
+		_, err := starlark.Call(th, m.Members["save_state"], starlark.Tuple{starlark.String(name), starlark.String(value)}, nil)
+		must.BeZero(t, err)
+	}
+
+	// post step
+	env := make(map[string]string)
+	var environ []string
+	commands, err := ReadFileCommands(getenv("GITHUB_STATE"))
+	must.BeZero(t, err)
+
+	for _, c := range commands {
+		env["STATE_"+c.Name] = c.Value
+		environ = append(environ, "STATE_"+c.Name+"="+c.Value)
+	}
+
+	th, m, _ = setupEnv(t, &buf, func(key string) string { return env[key] }, WithEnviron(func() []string { return environ }))
+
+	res, err := starlark.Call(th, m.Members["get_state"], starlark.Tuple{starlark.String("pid")}, nil)
+	must.BeZero(t, err)
+	should.BeEqual(t, res, starlark.String("1234"))
+
+	res, err = starlark.Call(th, m.Members["get_state"], starlark.Tuple{starlark.String("lines")}, nil)
+	must.BeZero(t, err)
+	should.BeEqual(t, res, starlark.String("first\nsecond"))
+
+	res, err = starlark.Call(th, m.Members["get_state"], starlark.Tuple{starlark.String("missing")}, nil)
+	must.BeZero(t, err)
+	should.BeEqual(t, res, starlark.String(""))
+
+	kwargs := []starlark.Tuple{{starlark.String("default"), starlark.None}}
+	res, err = starlark.Call(th, m.Members["get_state"], starlark.Tuple{starlark.String("missing")}, kwargs)
+	must.BeZero(t, err)
+	should.BeEqual(t, res, starlark.String(""))
+
+	kwargs = []starlark.Tuple{{starlark.String("default"), starlark.MakeInt(0)}}
+	res, err = starlark.Call(th, m.Members["get_state"], starlark.Tuple{starlark.String("missing")}, kwargs)
+	must.BeZero(t, err)
+	should.BeEqual(t, res, starlark.MakeInt(0))
+
+	res, err = starlark.Call(th, m.Members["state"], nil, nil)
+	must.BeZero(t, err)
+	should.BeEqual(t, res.String(), `{"lines": "first\nsecond", "pid": "1234"}`)
+
+	err = res.(*starlark.Dict).SetKey(starlark.String("pid"), starlark.String(""))
+	should.NotBeZero(t, err)
+
+	should.BeEqual(t, buf.String(), "")
+}
+
 func TestSetEnv(t *testing.T) {
 	var buf bytes.Buffer
 	th, m, getenv := setup(t, &buf, nil)
@@ -302,7 +1035,7 @@ func TestContext(t *testing.T) {
 	v, err = s.Attr("event")
 	must.BeZero(t, err)
 
-	event, ok := v.(*starlark.Dict)
+	event, ok := v.(*lazyEvent)
 	must.NotBeZero(t, ok)
 
 	pr, _, err := event.Get(starlark.String("pull_request"))
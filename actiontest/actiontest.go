@@ -0,0 +1,243 @@
+// Package actiontest runs tests for GitHub Actions written in Starlark.
+//
+// A test file is a Starlark file that loads (or defines) the action code and declares test_* functions.
+// Each test function is run as a subtest in a fresh synthetic runner environment
+// with githubactions and assert modules predeclared:
+//
+//	def test_greeting():
+//	    main()
+//	    assert.eq(assert.outputs()["greeting"], "Hello, World!")
+//	    assert.eq(len(assert.annotations("warning")), 0)
+//
+// See [Run] for details.
+package actiontest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/sethvargo/go-githubactions"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+
+	starlarkgithubactions "github.com/AlekSi/starlark-githubactions"
+)
+
+// Run runs all test_* functions from the Starlark file at the given path as subtests of t,
+// in the order of their definition.
+//
+// For each test function, the file is executed in a fresh synthetic runner environment
+// created with the given options (that may be nil), then the function is called.
+// The environment is always isolated from the process environment (see [starlarkgithubactions.LocalOptions.Isolated]),
+// so variables like GITHUB_TOKEN should be passed explicitly with Env option.
+// Workflow commands emitted by the script are captured and available for assertions;
+// print output is logged with t.Log.
+//
+// The assert module provides eq, ne, true, contains, and fails assertions that report failures
+// with t.Error, and outputs, env, state, path, annotations, summary, and log functions
+// that return what the script produced so far.
+func Run(t *testing.T, path string, opts *starlarkgithubactions.LocalOptions) {
+	t.Helper()
+
+	names, err := testNames(t, path, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(names) == 0 {
+		t.Fatalf("%s: no test_* functions found", path)
+	}
+
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			runTest(t, path, name, opts)
+		})
+	}
+}
+
+// test is a single test environment.
+type test struct {
+	tb          testing.TB
+	env         *starlarkgithubactions.LocalEnv
+	action      *starlarkgithubactions.Action
+	out         bytes.Buffer
+	th          *starlark.Thread
+	predeclared starlark.StringDict
+	dir         string                         // directory of the test file
+	loaded      map[string]starlark.StringDict // nil value means loading is in progress
+}
+
+// newTest creates a new test environment with the given options.
+func newTest(tb testing.TB, opts *starlarkgithubactions.LocalOptions) (*test, error) {
+	var o starlarkgithubactions.LocalOptions
+	if opts != nil {
+		o = *opts
+	}
+	o.Isolated = true
+
+	env, err := starlarkgithubactions.NewLocalEnv(&o)
+	if err != nil {
+		return nil, fmt.Errorf("newTest: %w", err)
+	}
+
+	tb.Cleanup(func() { env.Close() })
+
+	t := &test{
+		tb:  tb,
+		env: env,
+	}
+
+	ga := githubactions.New(
+		githubactions.WithWriter(&t.out),
+		githubactions.WithGetenv(env.Getenv),
+	)
+
+	t.action = starlarkgithubactions.New(
+		ga,
+		starlarkgithubactions.WithEnviron(env.Environ),
+		starlarkgithubactions.WithDeprecationWarnings(),
+	)
+
+	t.th = &starlark.Thread{
+		Name: tb.Name(),
+		Print: func(th *starlark.Thread, msg string) {
+			tb.Log(msg)
+		},
+		Load: t.load,
+	}
+
+	t.predeclared = starlark.StringDict{
+		"githubactions": starlarkgithubactions.NewModule("githubactions", t.action),
+		"assert":        newAssertModule(t),
+	}
+
+	return t, nil
+}
+
+// exec executes the test file at the given path.
+func (t *test) exec(path string) (starlark.StringDict, error) {
+	t.dir = filepath.Dir(path)
+
+	globals, err := starlark.ExecFileOptions(&syntax.FileOptions{}, t.th, path, nil, t.predeclared)
+	if err != nil {
+		return nil, errorWithBacktrace(err)
+	}
+
+	return globals, nil
+}
+
+// load implements [starlark.Thread] Load function.
+// Modules are loaded relative to the test file directory, with the same predeclared modules.
+func (t *test) load(th *starlark.Thread, module string) (starlark.StringDict, error) {
+	path := module
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(t.dir, path)
+	}
+
+	if t.loaded == nil {
+		t.loaded = make(map[string]starlark.StringDict)
+	}
+
+	globals, ok := t.loaded[path]
+	if ok {
+		if globals == nil {
+			return nil, fmt.Errorf("cycle in load graph involving %s", module)
+		}
+
+		return globals, nil
+	}
+
+	t.loaded[path] = nil
+
+	lth := &starlark.Thread{
+		Name:  module,
+		Print: th.Print,
+		Load:  th.Load,
+	}
+
+	globals, err := starlark.ExecFileOptions(&syntax.FileOptions{}, lth, path, nil, t.predeclared)
+	if err != nil {
+		delete(t.loaded, path)
+		return nil, err
+	}
+
+	t.loaded[path] = globals
+	return globals, nil
+}
+
+// errorWithBacktrace returns an error with Starlark backtrace, if available.
+func errorWithBacktrace(err error) error {
+	var evalErr *starlark.EvalError
+	if errors.As(err, &evalErr) {
+		return errors.New(evalErr.Backtrace())
+	}
+
+	return err
+}
+
+// testNames returns names of test functions in the file at the given path in the order of their definition.
+func testNames(tb testing.TB, path string, opts *starlarkgithubactions.LocalOptions) ([]string, error) {
+	t, err := newTest(tb, opts)
+	if err != nil {
+		return nil, fmt.Errorf("testNames: %w", err)
+	}
+
+	globals, err := t.exec(path)
+	if err != nil {
+		return nil, fmt.Errorf("testNames: %w", err)
+	}
+
+	var fns []*starlark.Function
+	for name, v := range globals {
+		if fn, ok := v.(*starlark.Function); ok && strings.HasPrefix(name, "test_") {
+			fns = append(fns, fn)
+		}
+	}
+
+	slices.SortFunc(fns, func(a, b *starlark.Function) int {
+		if a.Position().Line != b.Position().Line {
+			return int(a.Position().Line) - int(b.Position().Line)
+		}
+
+		return strings.Compare(a.Name(), b.Name())
+	})
+
+	res := make([]string, len(fns))
+	for i, fn := range fns {
+		res[i] = fn.Name()
+	}
+
+	return res, nil
+}
+
+// runTest executes the file at the given path in a fresh environment and calls the named test function.
+func runTest(tb testing.TB, path, name string, opts *starlarkgithubactions.LocalOptions) {
+	tb.Helper()
+
+	t, err := newTest(tb, opts)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	globals, err := t.exec(path)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	fn, ok := globals[name].(starlark.Callable)
+	if !ok {
+		tb.Fatalf("%s: function %q is not defined", path, name)
+	}
+
+	_, err = starlark.Call(t.th, fn, nil, nil)
+	t.action.Finish()
+
+	if err != nil {
+		tb.Error(errorWithBacktrace(err))
+	}
+}
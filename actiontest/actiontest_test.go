@@ -0,0 +1,120 @@
+package actiontest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AlekSi/should"
+
+	starlarkgithubactions "github.com/AlekSi/starlark-githubactions"
+)
+
+// recorder is a [testing.TB] that records errors instead of failing the test.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+// Error implements [testing.TB].
+func (r *recorder) Error(args ...any) {
+	r.errors = append(r.errors, fmt.Sprint(args...))
+}
+
+// Errorf implements [testing.TB].
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestRun(t *testing.T) {
+	Run(t, "testdata/greet_test.star", nil)
+}
+
+func TestRunNobody(t *testing.T) {
+	Run(t, "testdata/greet_nobody_test.star", &starlarkgithubactions.LocalOptions{
+		Inputs: map[string]string{"who": "nobody"},
+	})
+}
+
+func TestRunIsolated(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "ghp_process")
+	t.Setenv("GITHUB_REPOSITORY", "process/repo")
+
+	tt, err := newTest(t, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	should.BeEqual(t, tt.env.Getenv("GITHUB_TOKEN"), "")
+	should.BeEqual(t, tt.env.Getenv("GITHUB_REPOSITORY"), "")
+	should.BeEqual(t, tt.env.Getenv("PATH"), os.Getenv("PATH"))
+
+	tt, err = newTest(t, &starlarkgithubactions.LocalOptions{
+		Env: map[string]string{"GITHUB_TOKEN": "ghp_explicit"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	should.BeEqual(t, tt.env.Getenv("GITHUB_TOKEN"), "ghp_explicit")
+}
+
+func TestRunInputs(t *testing.T) {
+	r := &recorder{TB: t}
+	runTest(r, "testdata/greet_test.star", "test_default", &starlarkgithubactions.LocalOptions{
+		Inputs: map[string]string{"who": "nobody"},
+	})
+
+	expected := []string{
+		`testdata/greet_test.star:6:14: {} != {"greeting": "Hello, World!"}`,
+		"Traceback (most recent call last):\n" +
+			"  testdata/greet_test.star:7:27: in test_default\n" +
+			"Error: key \"GREETED\" not in dict",
+	}
+	should.BeEqual(t, r.errors, expected)
+}
+
+func TestRunDeprecated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deprecated.star")
+	script := `githubactions.declare_action(
+    name = "Greet",
+    description = "Greets someone.",
+    inputs = {"name": {"description": "Old name.", "deprecation_message": "Use who instead."}},
+)
+githubactions.get_input("name")
+`
+	if err := os.WriteFile(path, []byte(script), 0o666); err != nil {
+		t.Fatal(err)
+	}
+
+	tt, err := newTest(t, &starlarkgithubactions.LocalOptions{
+		Inputs: map[string]string{"name": "Alice"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = tt.exec(path); err != nil {
+		t.Fatal(err)
+	}
+
+	should.BeEqual(t, tt.out.String(), "::warning::Input 'name' has been deprecated with message: Use who instead.\n")
+}
+
+func TestParseAnnotation(t *testing.T) {
+	a, ok := parseAnnotation("::error endLine=3,file=a%2Cb.go,line=2,title=A%3A B::first%0Asecond %25")
+	should.BeEqual(t, ok, true)
+	should.BeEqual(t, a.String(), `annotation(col = None, end_column = None, end_line = 3, file = "a,b.go", `+
+		`level = "error", line = 2, message = "first\nsecond %", title = "A: B")`)
+
+	a, ok = parseAnnotation("::warning::message")
+	should.BeEqual(t, ok, true)
+	should.BeEqual(t, a.String(), `annotation(col = None, end_column = None, end_line = None, file = "", `+
+		`level = "warning", line = None, message = "message", title = "")`)
+
+	for _, line := range []string{"::debug::message", "::group::title", "message", "::error message"} {
+		_, ok = parseAnnotation(line)
+		should.BeEqual(t, ok, false)
+	}
+}
@@ -0,0 +1,328 @@
+package actiontest
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+
+	starlarkgithubactions "github.com/AlekSi/starlark-githubactions"
+)
+
+// newAssertModule constructs the assert Starlark module for the given test.
+func newAssertModule(t *test) *starlarkstruct.Module {
+	m := &starlarkstruct.Module{
+		Name:    "assert",
+		Members: make(starlark.StringDict),
+	}
+
+	for _, b := range []*starlark.Builtin{
+		starlark.NewBuiltin("eq", t.eq),
+		starlark.NewBuiltin("ne", t.ne),
+		starlark.NewBuiltin("true", t.isTrue),
+		starlark.NewBuiltin("contains", t.contains),
+		starlark.NewBuiltin("fails", t.fails),
+
+		starlark.NewBuiltin("outputs", t.fileCommands("GITHUB_OUTPUT")),
+		starlark.NewBuiltin("env", t.fileCommands("GITHUB_ENV")),
+		starlark.NewBuiltin("state", t.fileCommands("GITHUB_STATE")),
+		starlark.NewBuiltin("path", t.path),
+		starlark.NewBuiltin("annotations", t.annotations),
+		starlark.NewBuiltin("summary", t.summary),
+		starlark.NewBuiltin("log", t.log),
+	} {
+		m.Members[b.Name()] = b
+	}
+
+	return m
+}
+
+// errorf reports an assertion failure at the position of the Starlark caller.
+func (t *test) errorf(th *starlark.Thread, msg starlark.Value, format string, args ...any) {
+	s := fmt.Sprintf(format, args...)
+	if m, ok := starlark.AsString(msg); ok && m != "" {
+		s = m + ": " + s
+	}
+
+	t.tb.Errorf("%s: %s", th.CallFrame(1).Pos, s)
+}
+
+// eq implements assert.eq builtin.
+func (t *test) eq(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var x, y, msg starlark.Value
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "x", &x, "y", &y, "msg?", &msg); err != nil {
+		return nil, err
+	}
+
+	ok, err := starlark.Equal(x, y)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	if !ok {
+		t.errorf(th, msg, "%s != %s", x, y)
+	}
+
+	return starlark.None, nil
+}
+
+// ne implements assert.ne builtin.
+func (t *test) ne(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var x, y, msg starlark.Value
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "x", &x, "y", &y, "msg?", &msg); err != nil {
+		return nil, err
+	}
+
+	ok, err := starlark.Equal(x, y)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	if ok {
+		t.errorf(th, msg, "%s == %s", x, y)
+	}
+
+	return starlark.None, nil
+}
+
+// isTrue implements assert.true builtin.
+func (t *test) isTrue(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var cond, msg starlark.Value
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "cond", &cond, "msg?", &msg); err != nil {
+		return nil, err
+	}
+
+	if !cond.Truth() {
+		t.errorf(th, msg, "%s is not true", cond)
+	}
+
+	return starlark.None, nil
+}
+
+// contains implements assert.contains builtin.
+func (t *test) contains(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var container, item, msg starlark.Value
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "container", &container, "item", &item, "msg?", &msg); err != nil {
+		return nil, err
+	}
+
+	ok, err := starlark.Binary(syntax.IN, item, container)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	if !ok.Truth() {
+		t.errorf(th, msg, "%s does not contain %s", container, item)
+	}
+
+	return starlark.None, nil
+}
+
+// fails implements assert.fails builtin.
+// It calls the function and checks that it fails with an error matching the pattern.
+// It returns the error message.
+func (t *test) fails(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var f starlark.Callable
+	var pattern string
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "fn", &f, "pattern", &pattern); err != nil {
+		return nil, err
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	_, err = starlark.Call(th, f, nil, nil)
+	if err == nil {
+		t.errorf(th, nil, "%s succeeded, expected failure matching %q", f.Name(), pattern)
+		return starlark.None, nil
+	}
+
+	msg := err.Error()
+	if evalErr, ok := err.(*starlark.EvalError); ok {
+		msg = evalErr.Msg
+	}
+
+	if !re.MatchString(msg) {
+		t.errorf(th, nil, "%s failed with %q, expected failure matching %q", f.Name(), msg, pattern)
+	}
+
+	return starlark.String(msg), nil
+}
+
+// fileCommands returns a builtin that returns a frozen dict of name/value pairs from the given command file.
+// Later values override earlier ones.
+func (t *test) fileCommands(env string) func(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	return func(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := starlark.UnpackArgs(fn.Name(), args, kwargs); err != nil {
+			return nil, err
+		}
+
+		commands, err := starlarkgithubactions.ReadFileCommands(t.env.Getenv(env))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+		}
+
+		res := starlark.NewDict(len(commands))
+		for _, c := range commands {
+			_ = res.SetKey(starlark.String(c.Name), starlark.String(c.Value))
+		}
+
+		res.Freeze()
+		return res, nil
+	}
+}
+
+// path implements assert.path builtin.
+// It returns a frozen list of added path entries.
+func (t *test) path(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs); err != nil {
+		return nil, err
+	}
+
+	paths, err := starlarkgithubactions.ReadPathFile(t.env.Getenv("GITHUB_PATH"))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	elems := make([]starlark.Value, len(paths))
+	for i, p := range paths {
+		elems[i] = starlark.String(p)
+	}
+
+	res := starlark.NewList(elems)
+	res.Freeze()
+	return res, nil
+}
+
+// annotationLevels are workflow commands that create annotations.
+var annotationLevels = []string{"notice", "warning", "error"}
+
+// unescaper reverts escaping of workflow command data and properties.
+var unescaper = strings.NewReplacer("%0D", "\r", "%0A", "\n", "%3A", ":", "%2C", ",", "%25", "%")
+
+// parseAnnotation parses the annotation workflow command line like "::warning file=a.go,line=1::message".
+// It returns false if the line is not an annotation.
+func parseAnnotation(line string) (*starlarkstruct.Struct, bool) {
+	rest, ok := strings.CutPrefix(line, "::")
+	if !ok {
+		return nil, false
+	}
+
+	header, msg, ok := strings.Cut(rest, "::")
+	if !ok {
+		return nil, false
+	}
+
+	level, props, _ := strings.Cut(header, " ")
+	if !slices.Contains(annotationLevels, level) {
+		return nil, false
+	}
+
+	fields := starlark.StringDict{
+		"level":      starlark.String(level),
+		"message":    starlark.String(unescaper.Replace(msg)),
+		"file":       starlark.String(""),
+		"title":      starlark.String(""),
+		"line":       starlark.None,
+		"end_line":   starlark.None,
+		"col":        starlark.None,
+		"end_column": starlark.None,
+	}
+
+	names := map[string]string{
+		"file":      "file",
+		"title":     "title",
+		"line":      "line",
+		"endLine":   "end_line",
+		"col":       "col",
+		"endColumn": "end_column",
+	}
+
+	if props != "" {
+		for prop := range strings.SplitSeq(props, ",") {
+			k, v, _ := strings.Cut(prop, "=")
+			name, ok := names[k]
+			if !ok {
+				continue
+			}
+
+			v = unescaper.Replace(v)
+			if _, ok = fields[name].(starlark.String); ok {
+				fields[name] = starlark.String(v)
+				continue
+			}
+
+			if i, err := strconv.Atoi(v); err == nil {
+				fields[name] = starlark.MakeInt(i)
+			}
+		}
+	}
+
+	res := starlarkstruct.FromStringDict(starlark.String("annotation"), fields)
+	res.Freeze()
+	return res, true
+}
+
+// annotations implements assert.annotations builtin.
+// It returns a frozen list of annotation structs emitted so far, optionally filtered by level.
+func (t *test) annotations(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var level string
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "level??", &level); err != nil {
+		return nil, err
+	}
+
+	if level != "" && !slices.Contains(annotationLevels, level) {
+		return nil, fmt.Errorf("%s: invalid level %q, must be one of %v", fn.Name(), level, annotationLevels)
+	}
+
+	var elems []starlark.Value
+	for line := range strings.Lines(t.out.String()) {
+		a, ok := parseAnnotation(strings.TrimSuffix(line, "\n"))
+		if !ok {
+			continue
+		}
+
+		if l, _ := a.Attr("level"); level != "" && l != starlark.String(level) {
+			continue
+		}
+
+		elems = append(elems, a)
+	}
+
+	res := starlark.NewList(elems)
+	res.Freeze()
+	return res, nil
+}
+
+// summary implements assert.summary builtin.
+// It returns the job summary content written so far.
+func (t *test) summary(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs); err != nil {
+		return nil, err
+	}
+
+	b, err := os.ReadFile(t.env.Getenv("GITHUB_STEP_SUMMARY"))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	return starlark.String(b), nil
+}
+
+// log implements assert.log builtin.
+// It returns all workflow commands and log messages written so far.
+func (t *test) log(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs); err != nil {
+		return nil, err
+	}
+
+	return starlark.String(t.out.String()), nil
+}
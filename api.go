@@ -0,0 +1,466 @@
+package githubactions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+const (
+	// apiMaxRetries is the maximum number of retries of rate-limited API requests.
+	apiMaxRetries = 3
+
+	// apiMaxWait is the maximum time to wait for the rate limit reset.
+	apiMaxWait = 5 * time.Minute
+
+	// apiMaxErrorBody is the maximum length of non-JSON response body quoted in errors.
+	apiMaxErrorBody = 200
+)
+
+// WithHTTPClient sets the HTTP client used for GitHub API requests.
+// The default is [http.DefaultClient].
+func WithHTTPClient(c *http.Client) Option {
+	return func(a *Action) {
+		a.httpClient = c
+	}
+}
+
+// apiError represents an unsuccessful GitHub API response.
+type apiError struct {
+	method  string
+	url     string
+	status  int
+	message string
+}
+
+// Error implements error interface.
+func (e *apiError) Error() string {
+	res := fmt.Sprintf("%s %s: %d %s", e.method, e.url, e.status, http.StatusText(e.status))
+	if e.message != "" {
+		res += ": " + e.message
+	}
+
+	return res
+}
+
+// apiResponse represents a successful GitHub API response.
+type apiResponse struct {
+	header http.Header
+	value  starlark.Value
+}
+
+// newAPIModule constructs the api Starlark module for the given [Action].
+//
+// Requests are authenticated with the token input, or GITHUB_TOKEN environment variable.
+// Relative paths are resolved against GITHUB_API_URL (api_url of the context).
+func newAPIModule(a *Action) *starlarkstruct.Module {
+	m := &starlarkstruct.Module{
+		Name:    "api",
+		Members: make(starlark.StringDict),
+	}
+
+	for _, b := range []*starlark.Builtin{
+		starlark.NewBuiltin("get", a.APIGet),
+		starlark.NewBuiltin("post", a.APIPost),
+		starlark.NewBuiltin("patch", a.APIPatch),
+		starlark.NewBuiltin("put", a.APIPut),
+		starlark.NewBuiltin("delete", a.APIDelete),
+		starlark.NewBuiltin("paginate", a.APIPaginate),
+	} {
+		m.Members[b.Name()] = b
+	}
+
+	return m
+}
+
+// apiToken returns the token for GitHub API requests.
+func (a *Action) apiToken() string {
+	if token := a.a.GetInput("token"); token != "" {
+		return token
+	}
+
+	return a.a.Getenv("GITHUB_TOKEN")
+}
+
+// apiBaseURL returns GitHub API base URL without trailing slash.
+func (a *Action) apiBaseURL() string {
+	base := a.a.Getenv("GITHUB_API_URL")
+	if base == "" {
+		base = "https://api.github.com"
+	}
+
+	return strings.TrimSuffix(base, "/")
+}
+
+// apiURL resolves the given API path or absolute URL.
+func (a *Action) apiURL(p string) string {
+	if strings.HasPrefix(p, "https://") || strings.HasPrefix(p, "http://") {
+		return p
+	}
+
+	return a.apiBaseURL() + "/" + strings.TrimPrefix(p, "/")
+}
+
+// apiRetryAfter returns the time to wait before retrying the rate-limited request,
+// or false if the response is not rate-limited.
+func apiRetryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+
+	if s := resp.Header.Get("Retry-After"); s != "" {
+		if sec, err := strconv.Atoi(s); err == nil {
+			return time.Duration(sec) * time.Second, true
+		}
+	}
+
+	if resp.Header.Get("X-RateLimit-Remaining") != "0" {
+		return 0, false
+	}
+
+	reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return max(time.Unix(reset, 0).Sub(now), 0), true
+}
+
+// apiSendToken returns true if the token for the given API base URL can be sent to the target URL:
+// to the same scheme and host, and only over HTTPS (or plain HTTP to the loopback address, for testing).
+func apiSendToken(base, target *url.URL) bool {
+	if target.Scheme != base.Scheme || target.Host != base.Host {
+		return false
+	}
+
+	switch target.Scheme {
+	case "https":
+		return true
+	case "http":
+		ip := net.ParseIP(target.Hostname())
+		return target.Hostname() == "localhost" || (ip != nil && ip.IsLoopback())
+	default:
+		return false
+	}
+}
+
+// truncate returns s truncated to at most n bytes (without splitting runes), with "..." appended if truncated.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+
+	return s[:n] + "..."
+}
+
+// apiRequest sends GitHub API request with the given body encoded as JSON (if not nil)
+// and returns the decoded response.
+//
+// Rate-limited requests are retried after the time specified by the response headers.
+func (a *Action) apiRequest(ctx context.Context, method, p string, body starlark.Value, headers map[string]string) (*apiResponse, error) {
+	if err := a.checkSideEffects(); err != nil {
+		return nil, fmt.Errorf("apiRequest: %w", err)
+	}
+
+	u := a.apiURL(p)
+
+	var b []byte
+	if body != nil && body != starlark.None {
+		s, err := encodeJSON(body, "")
+		if err != nil {
+			return nil, fmt.Errorf("apiRequest: %w", err)
+		}
+
+		b = []byte(s)
+	}
+
+	var token string
+	if base, err := url.Parse(a.apiBaseURL()); err == nil {
+		if target, err := url.Parse(u); err == nil && apiSendToken(base, target) {
+			token = a.apiToken()
+		}
+	}
+
+	client := a.httpClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	for retry := 0; ; retry++ {
+		req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(b))
+		if err != nil {
+			return nil, fmt.Errorf("apiRequest: %w", err)
+		}
+
+		req.Header.Set("Accept", "application/vnd.github+json")
+		req.Header.Set("User-Agent", "starlark-githubactions")
+		req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+		if b != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("apiRequest: %w", err)
+		}
+
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if err != nil {
+			return nil, fmt.Errorf("apiRequest: %w", err)
+		}
+
+		if d, ok := apiRetryAfter(resp, time.Now()); ok && retry < apiMaxRetries && d <= apiMaxWait {
+			a.a.Debugf("%s %s: rate limited, retrying in %s", method, u, d)
+
+			t := time.NewTimer(d)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return nil, fmt.Errorf("apiRequest: %w", context.Cause(ctx))
+			case <-t.C:
+			}
+
+			continue
+		}
+
+		if resp.StatusCode >= 400 {
+			e := &apiError{
+				method:  method,
+				url:     u,
+				status:  resp.StatusCode,
+				message: truncate(strings.TrimSpace(string(respBody)), apiMaxErrorBody),
+			}
+
+			var msg struct {
+				Message string `json:"message"`
+			}
+			if json.Unmarshal(respBody, &msg) == nil && msg.Message != "" {
+				e.message = msg.Message
+			}
+
+			return nil, e
+		}
+
+		res := &apiResponse{
+			header: resp.Header,
+			value:  starlark.None,
+		}
+
+		if len(bytes.TrimSpace(respBody)) == 0 {
+			return res, nil
+		}
+
+		if mt, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); !strings.HasSuffix(mt, "json") {
+			res.value = starlark.String(respBody)
+			return res, nil
+		}
+
+		if res.value, err = decodeJSON(bytes.NewReader(respBody)); err != nil {
+			return nil, fmt.Errorf("apiRequest: %w", err)
+		}
+
+		return res, nil
+	}
+}
+
+// apiHeaders converts the given Starlark dict of headers.
+func apiHeaders(d *starlark.Dict) (map[string]string, error) {
+	if d == nil {
+		return nil, nil
+	}
+
+	res := make(map[string]string, d.Len())
+	for _, item := range d.Items() {
+		k, ok := starlark.AsString(item[0])
+		if !ok {
+			return nil, fmt.Errorf("apiHeaders: header name %s is not a string", item[0])
+		}
+
+		v, ok := starlark.AsString(item[1])
+		if !ok {
+			return nil, fmt.Errorf("apiHeaders: header %q value %s is not a string", k, item[1])
+		}
+
+		res[k] = v
+	}
+
+	return res, nil
+}
+
+// apiCall implements API builtins for the given HTTP method.
+func (a *Action) apiCall(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple, method string) (starlark.Value, error) {
+	var p string
+	var body starlark.Value
+	var headers *starlark.Dict
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "path", &p, "body??", &body, "headers??", &headers); err != nil {
+		return nil, err
+	}
+
+	h, err := apiHeaders(headers)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	ctx, done := a.cancelableContext(th)
+	defer done()
+
+	resp, err := a.apiRequest(ctx, method, p, body, h)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	return resp.value, nil
+}
+
+// APIGet sends GET request to the given GitHub API path and returns the decoded response.
+func (a *Action) APIGet(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	return a.apiCall(th, fn, args, kwargs, http.MethodGet)
+}
+
+// APIPost sends POST request to the given GitHub API path and returns the decoded response.
+func (a *Action) APIPost(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	return a.apiCall(th, fn, args, kwargs, http.MethodPost)
+}
+
+// APIPatch sends PATCH request to the given GitHub API path and returns the decoded response.
+func (a *Action) APIPatch(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	return a.apiCall(th, fn, args, kwargs, http.MethodPatch)
+}
+
+// APIPut sends PUT request to the given GitHub API path and returns the decoded response.
+func (a *Action) APIPut(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	return a.apiCall(th, fn, args, kwargs, http.MethodPut)
+}
+
+// APIDelete sends DELETE request to the given GitHub API path and returns the decoded response.
+func (a *Action) APIDelete(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	return a.apiCall(th, fn, args, kwargs, http.MethodDelete)
+}
+
+// nextLink returns the URL of the next page from the Link header, if any.
+func nextLink(h http.Header) string {
+	for _, v := range h.Values("Link") {
+		for link := range strings.SplitSeq(v, ",") {
+			target, params, ok := strings.Cut(strings.TrimSpace(link), ";")
+			if !ok {
+				continue
+			}
+
+			for param := range strings.SplitSeq(params, ";") {
+				if strings.TrimSpace(param) == `rel="next"` {
+					return strings.Trim(strings.TrimSpace(target), "<>")
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+// pageItems returns items of the paginated response.
+// Some endpoints return objects like {"total_count": 1, "items": [...]} instead of arrays.
+func pageItems(v starlark.Value) (*starlark.List, error) {
+	switch v := v.(type) {
+	case *starlark.List:
+		return v, nil
+
+	case *starlark.Dict:
+		var res *starlark.List
+		for _, item := range v.Items() {
+			l, ok := item[1].(*starlark.List)
+			if !ok {
+				continue
+			}
+
+			if res != nil {
+				return nil, fmt.Errorf("pageItems: response has several arrays")
+			}
+
+			res = l
+		}
+
+		if res != nil {
+			return res, nil
+		}
+	}
+
+	return nil, fmt.Errorf("pageItems: response %s is not an array", v.Type())
+}
+
+// APIPaginate sends GET requests to the given GitHub API path, following Link headers,
+// and returns a frozen list of all items from all pages.
+func (a *Action) APIPaginate(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var p string
+	var headers *starlark.Dict
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "path", &p, "headers??", &headers); err != nil {
+		return nil, err
+	}
+
+	h, err := apiHeaders(headers)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	ctx, done := a.cancelableContext(th)
+	defer done()
+
+	res, err := a.apiPaginate(ctx, p, h)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	return res, nil
+}
+
+// apiPaginate sends GET requests to the given GitHub API path, following Link headers,
+// and returns a frozen list of all items from all pages.
+func (a *Action) apiPaginate(ctx context.Context, p string, headers map[string]string) (*starlark.List, error) {
+	var elems []starlark.Value
+	for p != "" {
+		resp, err := a.apiRequest(ctx, http.MethodGet, p, nil, headers)
+		if err != nil {
+			return nil, fmt.Errorf("apiPaginate: %w", err)
+		}
+
+		items, err := pageItems(resp.value)
+		if err != nil {
+			return nil, fmt.Errorf("apiPaginate: %w", err)
+		}
+
+		elems = slices.AppendSeq(elems, starlark.Elements(items))
+		p = nextLink(resp.header)
+	}
+
+	res := starlark.NewList(elems)
+	res.Freeze()
+	return res, nil
+}
@@ -0,0 +1,283 @@
+package githubactions
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AlekSi/should"
+	"github.com/AlekSi/should/must"
+	"github.com/sethvargo/go-githubactions"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+// apiRequestLog is a request received by the test API server.
+type apiRequestLog struct {
+	Method        string
+	Path          string
+	Authorization string
+	Body          string
+}
+
+// newAPIServer starts a test server with the given handler that records requests,
+// and returns getenv function for it.
+func newAPIServer(tb testing.TB, handler http.Handler) (*[]apiRequestLog, githubactions.GetenvFunc) {
+	tb.Helper()
+
+	var reqs []apiRequestLog
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		must.BeZero(tb, err)
+
+		reqs = append(reqs, apiRequestLog{
+			Method:        r.Method,
+			Path:          r.URL.RequestURI(),
+			Authorization: r.Header.Get("Authorization"),
+			Body:          string(b),
+		})
+
+		handler.ServeHTTP(w, r)
+	}))
+	tb.Cleanup(s.Close)
+
+	return &reqs, func(key string) string {
+		switch key {
+		case "GITHUB_API_URL":
+			return s.URL
+		case "GITHUB_TOKEN":
+			return "ghs_test"
+		default:
+			return ""
+		}
+	}
+}
+
+// writeJSON writes the given value as JSON response.
+func writeJSON(tb testing.TB, w http.ResponseWriter, status int, v any) {
+	tb.Helper()
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	must.BeZero(tb, json.NewEncoder(w).Encode(v))
+}
+
+// apiCall calls the api module builtin.
+func apiCall(th *starlark.Thread, m *starlarkstruct.Module, name string, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	api := m.Members["api"].(*starlarkstruct.Module)
+	return starlark.Call(th, api.Members[name], args, kwargs)
+}
+
+func TestAPI(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/o/r", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, http.StatusOK, map[string]any{"id": 42, "full_name": "o/r"})
+	})
+	mux.HandleFunc("POST /repos/o/r/issues/1/labels", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, http.StatusOK, []any{map[string]any{"name": "bug"}})
+	})
+	mux.HandleFunc("DELETE /repos/o/r/issues/1/labels/bug", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET /repos/o/r/issues/2", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, http.StatusNotFound, map[string]any{"message": "Not Found"})
+	})
+
+	reqs, getenv := newAPIServer(t, mux)
+
+	var buf bytes.Buffer
+	th, m, _ := setupEnv(t, &buf, getenv)
+
+	res, err := apiCall(th, m, "get", starlark.Tuple{starlark.String("/repos/o/r")}, nil)
+	must.BeZero(t, err)
+	should.BeEqual(t, res.String(), `{"full_name": "o/r", "id": 42}`)
+	must.NotBeZero(t, res.(*starlark.Dict).SetKey(starlark.String("id"), starlark.None))
+
+	labels := starlark.NewDict(1)
+	must.BeZero(t, labels.SetKey(starlark.String("labels"), starlark.NewList([]starlark.Value{starlark.String("bug")})))
+	res, err = apiCall(th, m, "post", starlark.Tuple{starlark.String("repos/o/r/issues/1/labels"), labels}, nil)
+	must.BeZero(t, err)
+	should.BeEqual(t, res.String(), `[{"name": "bug"}]`)
+
+	res, err = apiCall(th, m, "delete", starlark.Tuple{starlark.String("repos/o/r/issues/1/labels/bug")}, nil)
+	must.BeZero(t, err)
+	should.BeEqual(t, res, starlark.None)
+
+	_, err = apiCall(th, m, "get", starlark.Tuple{starlark.String("repos/o/r/issues/2")}, nil)
+	must.NotBeZero(t, err)
+	should.BeEqual(t, err.Error(), "get: GET "+getenv("GITHUB_API_URL")+"/repos/o/r/issues/2: 404 Not Found: Not Found")
+
+	expected := []apiRequestLog{
+		{Method: "GET", Path: "/repos/o/r", Authorization: "Bearer ghs_test"},
+		{Method: "POST", Path: "/repos/o/r/issues/1/labels", Authorization: "Bearer ghs_test", Body: `{"labels":["bug"]}`},
+		{Method: "DELETE", Path: "/repos/o/r/issues/1/labels/bug", Authorization: "Bearer ghs_test"},
+		{Method: "GET", Path: "/repos/o/r/issues/2", Authorization: "Bearer ghs_test"},
+	}
+	should.BeEqual(t, *reqs, expected)
+
+	should.BeEqual(t, buf.String(), "")
+}
+
+func TestAPIPaginate(t *testing.T) {
+	var url string
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/o/r/pulls/1/files", func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page < 2 {
+			w.Header().Set("Link", `<`+url+`/repos/o/r/pulls/1/files?page=`+strconv.Itoa(page+1)+`>; rel="next", <`+url+`/repos/o/r/pulls/1/files?page=2>; rel="last"`)
+		}
+
+		writeJSON(t, w, http.StatusOK, []any{map[string]any{"filename": "f" + strconv.Itoa(page)}})
+	})
+	mux.HandleFunc("GET /search/issues", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, http.StatusOK, map[string]any{"total_count": 1, "incomplete_results": false, "items": []any{1}})
+	})
+
+	reqs, getenv := newAPIServer(t, mux)
+	url = getenv("GITHUB_API_URL")
+
+	var buf bytes.Buffer
+	th, m, _ := setupEnv(t, &buf, getenv)
+
+	res, err := apiCall(th, m, "paginate", starlark.Tuple{starlark.String("repos/o/r/pulls/1/files")}, nil)
+	must.BeZero(t, err)
+	should.BeEqual(t, res.String(), `[{"filename": "f0"}, {"filename": "f1"}, {"filename": "f2"}]`)
+	should.BeEqual(t, len(*reqs), 3)
+
+	res, err = apiCall(th, m, "paginate", starlark.Tuple{starlark.String("search/issues?q=x")}, nil)
+	must.BeZero(t, err)
+	should.BeEqual(t, res.String(), `[1]`)
+}
+
+func TestAPIRateLimit(t *testing.T) {
+	var n int
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /rate", func(w http.ResponseWriter, r *http.Request) {
+		n++
+		switch n {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			writeJSON(t, w, http.StatusTooManyRequests, map[string]any{"message": "secondary rate limit"})
+		case 2:
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(-time.Second).Unix(), 10))
+			writeJSON(t, w, http.StatusForbidden, map[string]any{"message": "API rate limit exceeded"})
+		default:
+			writeJSON(t, w, http.StatusOK, true)
+		}
+	})
+	mux.HandleFunc("GET /forbidden", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		writeJSON(t, w, http.StatusForbidden, map[string]any{"message": "API rate limit exceeded"})
+	})
+
+	_, getenv := newAPIServer(t, mux)
+
+	var buf bytes.Buffer
+	th, m, _ := setupEnv(t, &buf, getenv)
+
+	res, err := apiCall(th, m, "get", starlark.Tuple{starlark.String("rate")}, nil)
+	must.BeZero(t, err)
+	should.BeEqual(t, res, starlark.True)
+	should.BeEqual(t, n, 3)
+
+	_, err = apiCall(th, m, "get", starlark.Tuple{starlark.String("forbidden")}, nil)
+	must.NotBeZero(t, err)
+	should.BeEqual(t, err.Error(), "get: GET "+getenv("GITHUB_API_URL")+"/forbidden: 403 Forbidden: API rate limit exceeded")
+}
+
+func TestAPIToken(t *testing.T) {
+	reqs, getenv := newAPIServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	var buf bytes.Buffer
+	th, m, _ := setupEnv(t, &buf, func(key string) string {
+		if key == "INPUT_TOKEN" {
+			return "ghp_input"
+		}
+		return getenv(key)
+	})
+
+	_, err := apiCall(th, m, "put", starlark.Tuple{starlark.String("user/starred/o/r")}, nil)
+	must.BeZero(t, err)
+
+	// absolute URLs on other hosts do not get the token
+	_, err = apiCall(th, m, "get", starlark.Tuple{starlark.String(strings.Replace(getenv("GITHUB_API_URL"), "127.0.0.1", "localhost", 1) + "/other")}, nil)
+	must.BeZero(t, err)
+
+	should.BeEqual(t, (*reqs)[0].Authorization, "Bearer ghp_input")
+	should.BeEqual(t, (*reqs)[1].Authorization, "")
+}
+
+func TestAPISendToken(t *testing.T) {
+	for name, tc := range map[string]struct {
+		base     string
+		target   string
+		expected bool
+	}{
+		"HTTPS": {
+			base:     "https://api.github.com",
+			target:   "https://api.github.com/repos/o/r",
+			expected: true,
+		},
+		"HTTPDowngrade": {
+			base:     "https://api.github.com",
+			target:   "http://api.github.com/repos/o/r",
+			expected: false,
+		},
+		"OtherHost": {
+			base:     "https://api.github.com",
+			target:   "https://example.com/repos/o/r",
+			expected: false,
+		},
+		"OtherPort": {
+			base:     "https://api.github.com",
+			target:   "https://api.github.com:8443/repos/o/r",
+			expected: false,
+		},
+		"HTTP": {
+			base:     "http://ghes.example.com/api/v3",
+			target:   "http://ghes.example.com/api/v3/repos/o/r",
+			expected: false,
+		},
+		"HTTPLoopback": {
+			base:     "http://127.0.0.1:8080",
+			target:   "http://127.0.0.1:8080/repos/o/r",
+			expected: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			base, err := url.Parse(tc.base)
+			must.BeZero(t, err)
+			target, err := url.Parse(tc.target)
+			must.BeZero(t, err)
+
+			should.BeEqual(t, apiSendToken(base, target), tc.expected)
+		})
+	}
+}
+
+func TestAPIErrorTruncate(t *testing.T) {
+	_, getenv := newAPIServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, strings.Repeat("я", apiMaxErrorBody), http.StatusBadGateway)
+	}))
+
+	var buf bytes.Buffer
+	th, m, _ := setupEnv(t, &buf, getenv)
+
+	_, err := apiCall(th, m, "get", starlark.Tuple{starlark.String("/repos/o/r")}, nil)
+	must.NotBeZero(t, err)
+
+	expected := "get: GET " + getenv("GITHUB_API_URL") + "/repos/o/r: 502 Bad Gateway: " +
+		strings.Repeat("я", apiMaxErrorBody/2) + "..."
+	should.BeEqual(t, err.Error(), expected)
+}
@@ -0,0 +1,336 @@
+package githubactions
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/sethvargo/go-githubactions"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+// checkAnnotationsBatch is the maximum number of annotations per check run update request.
+const checkAnnotationsBatch = 50
+
+// checkConclusions are valid check run conclusions.
+var checkConclusions = []string{
+	"action_required", "cancelled", "failure", "neutral", "success", "skipped", "timed_out",
+}
+
+// checkLevels maps check run annotation levels to functions emitting workflow commands
+// that are used when there is no token.
+var checkLevels = map[string]func(a *githubactions.Action, msg string, args ...any){
+	"notice":  (*githubactions.Action).Noticef,
+	"warning": (*githubactions.Action).Warningf,
+	"failure": (*githubactions.Action).Errorf,
+}
+
+// checkRun represents a check run created by the checks module.
+type checkRun struct {
+	a     *Action
+	path  string // API path of the check run; empty if there is no token
+	name  string
+	title string
+
+	m           sync.Mutex
+	annotations []starlark.Value
+	completed   bool
+}
+
+// newChecksModule constructs the checks Starlark module for the given [Action].
+//
+// Without a token, check runs are not created, and annotations are emitted as workflow commands instead.
+func newChecksModule(a *Action) *starlarkstruct.Module {
+	m := &starlarkstruct.Module{
+		Name:    "checks",
+		Members: make(starlark.StringDict),
+	}
+
+	for _, b := range []*starlark.Builtin{
+		starlark.NewBuiltin("create", a.ChecksCreate),
+	} {
+		m.Members[b.Name()] = b
+	}
+
+	return m
+}
+
+// ChecksCreate creates an in-progress check run with the given name for the sha (GITHUB_SHA by default).
+//
+// It returns a struct with the id field (None if there is no token) and two builtins:
+// annotate(path, line, message, level="warning", end_line=None, title=None) that adds an annotation,
+// sending them in batches, and finish(conclusion, summary=None) that sends the remaining annotations
+// and completes the check run. Check runs that are not finished by the script are completed
+// with the failure conclusion by [Action.Finish].
+//
+// Masked values are replaced in annotation messages and titles, and in the check run title and summary.
+func (a *Action) ChecksCreate(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var name, title, sha string
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "name", &name, "title??", &title, "sha??", &sha); err != nil {
+		return nil, err
+	}
+
+	if title == "" {
+		title = name
+	}
+
+	if sha == "" {
+		sha = a.a.Getenv("GITHUB_SHA")
+	}
+
+	cr := &checkRun{
+		a:     a,
+		name:  name,
+		title: title,
+	}
+
+	id := starlark.Value(starlark.None)
+
+	if a.apiToken() != "" {
+		repo, err := a.repoPath()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+		}
+
+		ctx, done := a.cancelableContext(th)
+		defer done()
+
+		body := newDict(starlark.StringDict{
+			"name":     starlark.String(name),
+			"head_sha": starlark.String(sha),
+			"status":   starlark.String("in_progress"),
+		})
+
+		resp, err := a.apiRequest(ctx, http.MethodPost, repo+"/check-runs", body, nil)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+		}
+
+		d, ok := resp.value.(*starlark.Dict)
+		if ok {
+			id, _, _ = d.Get(starlark.String("id"))
+		}
+
+		if _, ok = id.(starlark.Int); !ok {
+			return nil, fmt.Errorf("%s: unexpected response %s", fn.Name(), resp.value)
+		}
+
+		cr.path = repo + "/check-runs/" + id.String()
+	}
+
+	a.checkRunsM.Lock()
+	a.checkRuns = append(a.checkRuns, cr)
+	a.checkRunsM.Unlock()
+
+	res := starlarkstruct.FromStringDict(starlark.String("check_run"), starlark.StringDict{
+		"id":       id,
+		"annotate": starlark.NewBuiltin("annotate", cr.annotate),
+		"finish":   starlark.NewBuiltin("finish", cr.finish),
+	})
+
+	res.Freeze()
+	return res, nil
+}
+
+// update sends the check run update request with the given fields and output annotations.
+// Empty summary is replaced with the title, as the API requires one.
+// Masked values are replaced in the title and summary.
+func (cr *checkRun) update(ctx context.Context, fields starlark.StringDict, summary string, annotations []starlark.Value) error {
+	if summary == "" {
+		summary = cr.title
+	}
+
+	output := starlark.StringDict{
+		"title":       starlark.String(cr.a.mask(cr.title)),
+		"summary":     starlark.String(cr.a.mask(summary)),
+		"annotations": starlark.NewList(annotations),
+	}
+
+	body := make(starlark.StringDict, len(fields)+1)
+	for k, v := range fields {
+		body[k] = v
+	}
+	body["output"] = newDict(output)
+
+	if _, err := cr.a.apiRequest(ctx, http.MethodPatch, cr.path, newDict(body), nil); err != nil {
+		return fmt.Errorf("update: %w", err)
+	}
+
+	return nil
+}
+
+// annotate implements check run's annotate builtin.
+func (cr *checkRun) annotate(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var path, message, title string
+	var line, endLine position
+	level := "warning"
+	if err := starlark.UnpackArgs(
+		fn.Name(), args, kwargs,
+		"path", &path,
+		"line", &line,
+		"message", &message,
+		"level?", &level,
+		"end_line??", &endLine,
+		"title??", &title,
+	); err != nil {
+		return nil, err
+	}
+
+	logf, ok := checkLevels[level]
+	if !ok {
+		return nil, fmt.Errorf("%s: invalid level %q, must be one of notice, warning, failure", fn.Name(), level)
+	}
+
+	if endLine == 0 {
+		endLine = line
+	}
+
+	if endLine < line {
+		return nil, fmt.Errorf("%s: end_line %d is less than line %d", fn.Name(), endLine, line)
+	}
+
+	cr.m.Lock()
+	defer cr.m.Unlock()
+
+	if cr.completed {
+		return nil, fmt.Errorf("%s: check run is already finished", fn.Name())
+	}
+
+	if cr.path == "" {
+		fields := map[string]string{
+			"file": path,
+			"line": strconv.Itoa(int(line)),
+		}
+		if endLine != line {
+			fields["endLine"] = strconv.Itoa(int(endLine))
+		}
+		if title != "" {
+			fields["title"] = cr.a.mask(title)
+		}
+
+		logf(cr.a.a.WithFieldsMap(fields), "%s", cr.a.mask(message))
+		return starlark.None, nil
+	}
+
+	annotation := starlark.StringDict{
+		"path":             starlark.String(path),
+		"start_line":       starlark.MakeInt(int(line)),
+		"end_line":         starlark.MakeInt(int(endLine)),
+		"annotation_level": starlark.String(level),
+		"message":          starlark.String(cr.a.mask(message)),
+	}
+	if title != "" {
+		annotation["title"] = starlark.String(cr.a.mask(title))
+	}
+
+	cr.annotations = append(cr.annotations, newDict(annotation))
+	if len(cr.annotations) < checkAnnotationsBatch {
+		return starlark.None, nil
+	}
+
+	ctx, done := cr.a.cancelableContext(th)
+	defer done()
+
+	// keep the batch buffered until it is sent, so that it is sent again after a failure
+	batch := cr.annotations[:checkAnnotationsBatch]
+	if err := cr.update(ctx, nil, "", batch); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	cr.annotations = cr.annotations[len(batch):]
+
+	return starlark.None, nil
+}
+
+// finish implements check run's finish builtin.
+func (cr *checkRun) finish(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var conclusion, summary string
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "conclusion", &conclusion, "summary??", &summary); err != nil {
+		return nil, err
+	}
+
+	if !slices.Contains(checkConclusions, conclusion) {
+		return nil, fmt.Errorf("%s: invalid conclusion %q, must be one of %v", fn.Name(), conclusion, checkConclusions)
+	}
+
+	cr.m.Lock()
+	defer cr.m.Unlock()
+
+	if cr.completed {
+		return nil, fmt.Errorf("%s: check run is already finished", fn.Name())
+	}
+
+	ctx, done := cr.a.cancelableContext(th)
+	defer done()
+
+	if err := cr.complete(ctx, conclusion, summary); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	return starlark.None, nil
+}
+
+// complete sends the remaining annotations and completes the check run with the given conclusion.
+// Without a token, the summary is added to the step summary instead.
+// If the update fails, the check run stays incomplete with annotations buffered, so it can be completed again.
+//
+// cr.m must be held.
+func (cr *checkRun) complete(ctx context.Context, conclusion, summary string) error {
+	if cr.path == "" {
+		if summary != "" {
+			cr.a.a.AddStepSummary(cr.a.mask(summary))
+		}
+
+		cr.completed = true
+		return nil
+	}
+
+	// annotations left after failed updates may exceed the batch size
+	for len(cr.annotations) > checkAnnotationsBatch {
+		if err := cr.update(ctx, nil, "", cr.annotations[:checkAnnotationsBatch]); err != nil {
+			return err
+		}
+
+		cr.annotations = cr.annotations[checkAnnotationsBatch:]
+	}
+
+	fields := starlark.StringDict{
+		"status":     starlark.String("completed"),
+		"conclusion": starlark.String(conclusion),
+	}
+
+	if err := cr.update(ctx, fields, summary, cr.annotations); err != nil {
+		return err
+	}
+
+	cr.annotations = nil
+	cr.completed = true
+
+	return nil
+}
+
+// finishCheckRuns completes check runs that were not finished by the script; see [Action.Finish].
+func (a *Action) finishCheckRuns() {
+	a.checkRunsM.Lock()
+	runs := a.checkRuns
+	a.checkRuns = nil
+	a.checkRunsM.Unlock()
+
+	for _, cr := range runs {
+		cr.m.Lock()
+
+		if !cr.completed {
+			a.a.Warningf("%s", a.mask(fmt.Sprintf("check run %q was not finished by the script", cr.name)))
+
+			if err := cr.complete(context.Background(), "failure", ""); err != nil {
+				a.a.Warningf("%s", a.mask(fmt.Sprintf("check run %q: %s", cr.name, err)))
+			}
+		}
+
+		cr.m.Unlock()
+	}
+}
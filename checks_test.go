@@ -0,0 +1,313 @@
+package githubactions
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/AlekSi/should"
+	"github.com/AlekSi/should/must"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+// checksCreate calls checks.create builtin and returns the check run struct.
+func checksCreate(tb testing.TB, th *starlark.Thread, m *starlarkstruct.Module, name string) *starlarkstruct.Struct {
+	tb.Helper()
+
+	checks := m.Members["checks"].(*starlarkstruct.Module)
+	res, err := starlark.Call(th, checks.Members["create"], starlark.Tuple{starlark.String(name)}, nil)
+	must.BeZero(tb, err)
+
+	return res.(*starlarkstruct.Struct)
+}
+
+// checkRunCall calls the check run builtin.
+func checkRunCall(th *starlark.Thread, cr *starlarkstruct.Struct, name string, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	b, err := cr.Attr(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return starlark.Call(th, b, args, kwargs)
+}
+
+// annotateArgs returns arguments for annotate builtin.
+func annotateArgs(path string, line int, message string) starlark.Tuple {
+	return starlark.Tuple{starlark.String(path), starlark.MakeInt(line), starlark.String(message)}
+}
+
+func TestChecks(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /repos/o/r/check-runs", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, http.StatusCreated, map[string]any{"id": 5})
+	})
+	mux.HandleFunc("PATCH /repos/o/r/check-runs/5", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, http.StatusOK, map[string]any{"id": 5})
+	})
+
+	reqs, getenv := newAPIServer(t, mux)
+
+	var buf bytes.Buffer
+	th, m, _ := setupEnv(t, &buf, func(key string) string {
+		switch key {
+		case "GITHUB_REPOSITORY":
+			return "o/r"
+		case "GITHUB_SHA":
+			return "abc"
+		default:
+			return getenv(key)
+		}
+	})
+
+	_, err := starlark.Call(th, m.Members["add_mask"], starlark.Tuple{starlark.String("s3cret")}, nil)
+	must.BeZero(t, err)
+
+	cr := checksCreate(t, th, m, "lint")
+	id, _ := cr.Attr("id")
+	should.BeEqual(t, id, starlark.MakeInt(5))
+
+	for i := range 120 {
+		_, err := checkRunCall(th, cr, "annotate", annotateArgs("main.go", i+1, "bad"), nil)
+		must.BeZero(t, err)
+	}
+
+	kwargs := []starlark.Tuple{
+		{starlark.String("level"), starlark.String("failure")},
+		{starlark.String("end_line"), starlark.MakeInt(3)},
+		{starlark.String("title"), starlark.String("Oops s3cret")},
+	}
+	_, err = checkRunCall(th, cr, "annotate", annotateArgs("go.mod", 2, "worse s3cret"), kwargs)
+	must.BeZero(t, err)
+
+	kwargs = []starlark.Tuple{{starlark.String("level"), starlark.String("error")}}
+	_, err = checkRunCall(th, cr, "annotate", annotateArgs("go.mod", 2, "worse"), kwargs)
+	must.NotBeZero(t, err)
+	should.BeEqual(t, err.Error(), `annotate: invalid level "error", must be one of notice, warning, failure`)
+
+	_, err = checkRunCall(th, cr, "finish", starlark.Tuple{starlark.String("ok")}, nil)
+	must.NotBeZero(t, err)
+
+	_, err = checkRunCall(th, cr, "finish", starlark.Tuple{starlark.String("failure"), starlark.String("121 problems, s3cret")}, nil)
+	must.BeZero(t, err)
+
+	_, err = checkRunCall(th, cr, "finish", starlark.Tuple{starlark.String("failure")}, nil)
+	must.NotBeZero(t, err)
+	should.BeEqual(t, err.Error(), "finish: check run is already finished")
+
+	must.BeEqual(t, len(*reqs), 4)
+	should.BeEqual(t, (*reqs)[0].Body, `{"head_sha":"abc","name":"lint","status":"in_progress"}`)
+
+	type annotation struct {
+		Path      string `json:"path"`
+		StartLine int    `json:"start_line"`
+		EndLine   int    `json:"end_line"`
+		Level     string `json:"annotation_level"`
+		Message   string `json:"message"`
+		Title     string `json:"title"`
+	}
+
+	type update struct {
+		Status     string `json:"status"`
+		Conclusion string `json:"conclusion"`
+		Output     struct {
+			Title       string       `json:"title"`
+			Summary     string       `json:"summary"`
+			Annotations []annotation `json:"annotations"`
+		} `json:"output"`
+	}
+
+	var updates []update
+	for _, req := range (*reqs)[1:] {
+		should.BeEqual(t, req.Method+" "+req.Path, "PATCH /repos/o/r/check-runs/5")
+
+		var u update
+		must.BeZero(t, json.Unmarshal([]byte(req.Body), &u))
+		updates = append(updates, u)
+	}
+
+	should.BeEqual(t, len(updates[0].Output.Annotations), 50)
+	should.BeEqual(t, updates[0].Output.Annotations[0], annotation{
+		Path: "main.go", StartLine: 1, EndLine: 1, Level: "warning", Message: "bad",
+	})
+	should.BeEqual(t, updates[0].Output.Summary, "lint")
+	should.BeEqual(t, updates[0].Status, "")
+
+	should.BeEqual(t, len(updates[1].Output.Annotations), 50)
+	should.BeEqual(t, updates[1].Output.Annotations[0].StartLine, 51)
+
+	should.BeEqual(t, len(updates[2].Output.Annotations), 21)
+	should.BeEqual(t, updates[2].Output.Annotations[20], annotation{
+		Path: "go.mod", StartLine: 2, EndLine: 3, Level: "failure", Message: "worse ***", Title: "Oops ***",
+	})
+	should.BeEqual(t, updates[2].Status, "completed")
+	should.BeEqual(t, updates[2].Conclusion, "failure")
+	should.BeEqual(t, updates[2].Output.Title, "lint")
+	should.BeEqual(t, updates[2].Output.Summary, "121 problems, ***")
+
+	should.BeEqual(t, buf.String(), "::add-mask::s3cret\n")
+}
+
+func TestChecksUpdateError(t *testing.T) {
+	var fail bool
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /repos/o/r/check-runs", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, http.StatusCreated, map[string]any{"id": 5})
+	})
+	mux.HandleFunc("PATCH /repos/o/r/check-runs/5", func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			writeJSON(t, w, http.StatusUnprocessableEntity, map[string]any{"message": "Validation Failed"})
+			return
+		}
+		writeJSON(t, w, http.StatusOK, map[string]any{"id": 5})
+	})
+
+	reqs, getenv := newAPIServer(t, mux)
+
+	var buf bytes.Buffer
+	th, m, _ := setupEnv(t, &buf, func(key string) string {
+		if key == "GITHUB_REPOSITORY" {
+			return "o/r"
+		}
+		return getenv(key)
+	})
+
+	cr := checksCreate(t, th, m, "lint")
+
+	fail = true
+	for i := range 50 {
+		_, err := checkRunCall(th, cr, "annotate", annotateArgs("main.go", i+1, "bad"), nil)
+		if i < 49 {
+			must.BeZero(t, err)
+		} else {
+			must.NotBeZero(t, err)
+		}
+	}
+
+	// failed batch is sent with the next annotation
+	fail = false
+	_, err := checkRunCall(th, cr, "annotate", annotateArgs("main.go", 51, "bad"), nil)
+	must.BeZero(t, err)
+
+	fail = true
+	_, err = checkRunCall(th, cr, "finish", starlark.Tuple{starlark.String("failure")}, nil)
+	must.NotBeZero(t, err)
+
+	// not completed, remaining annotation is kept
+	fail = false
+	_, err = checkRunCall(th, cr, "finish", starlark.Tuple{starlark.String("failure")}, nil)
+	must.BeZero(t, err)
+
+	must.BeEqual(t, len(*reqs), 5)
+
+	type update struct {
+		Status string `json:"status"`
+		Output struct {
+			Annotations []struct {
+				StartLine int `json:"start_line"`
+			} `json:"annotations"`
+		} `json:"output"`
+	}
+
+	var updates []update
+	for _, req := range (*reqs)[1:] {
+		var u update
+		must.BeZero(t, json.Unmarshal([]byte(req.Body), &u))
+		updates = append(updates, u)
+	}
+
+	should.BeEqual(t, len(updates[0].Output.Annotations), 50)
+	should.BeEqual(t, len(updates[1].Output.Annotations), 50)
+	should.BeEqual(t, updates[1].Output.Annotations[0].StartLine, 1)
+	should.BeEqual(t, len(updates[2].Output.Annotations), 1)
+	should.BeEqual(t, updates[2].Status, "completed")
+	should.BeEqual(t, updates[3].Output.Annotations, updates[2].Output.Annotations)
+	should.BeEqual(t, updates[3].Output.Annotations[0].StartLine, 51)
+	should.BeEqual(t, updates[3].Status, "completed")
+}
+
+func TestChecksNoToken(t *testing.T) {
+	var buf bytes.Buffer
+	th, m, getenv := setupEnv(t, &buf, func(key string) string {
+		return ""
+	})
+
+	_, err := starlark.Call(th, m.Members["add_mask"], starlark.Tuple{starlark.String("s3cret")}, nil)
+	must.BeZero(t, err)
+
+	cr := checksCreate(t, th, m, "lint")
+	id, _ := cr.Attr("id")
+	should.BeEqual(t, id, starlark.None)
+
+	_, err = checkRunCall(th, cr, "annotate", annotateArgs("main.go", 1, "bad"), nil)
+	must.BeZero(t, err)
+
+	kwargs := []starlark.Tuple{
+		{starlark.String("level"), starlark.String("failure")},
+		{starlark.String("end_line"), starlark.MakeInt(3)},
+		{starlark.String("title"), starlark.String("Oops s3cret")},
+	}
+	_, err = checkRunCall(th, cr, "annotate", annotateArgs("go.mod", 2, "worse s3cret"), kwargs)
+	must.BeZero(t, err)
+
+	_, err = checkRunCall(th, cr, "finish", starlark.Tuple{starlark.String("failure"), starlark.String("2 problems")}, nil)
+	must.BeZero(t, err)
+
+	expected := "::add-mask::s3cret\n" +
+		"::warning file=main.go,line=1::bad\n" +
+		"::error endLine=3,file=go.mod,line=2,title=Oops ***::worse ***\n"
+	should.BeEqual(t, buf.String(), expected)
+
+	b, err := os.ReadFile(getenv("GITHUB_STEP_SUMMARY"))
+	must.BeZero(t, err)
+	should.BeEqual(t, string(b), "2 problems\n")
+}
+
+func TestChecksNotFinished(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /repos/o/r/check-runs", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, http.StatusCreated, map[string]any{"id": 5})
+	})
+	mux.HandleFunc("PATCH /repos/o/r/check-runs/5", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, http.StatusOK, map[string]any{"id": 5})
+	})
+
+	reqs, getenv := newAPIServer(t, mux)
+
+	var a *Action
+	var buf bytes.Buffer
+	th, m, _ := setupEnv(t, &buf, func(key string) string {
+		if key == "GITHUB_REPOSITORY" {
+			return "o/r"
+		}
+		return getenv(key)
+	}, func(action *Action) { a = action })
+
+	cr := checksCreate(t, th, m, "lint")
+
+	_, err := checkRunCall(th, cr, "annotate", annotateArgs("main.go", 1, "bad"), nil)
+	must.BeZero(t, err)
+
+	a.Finish()
+
+	must.BeEqual(t, len(*reqs), 2)
+	should.BeEqual(t, (*reqs)[1].Method+" "+(*reqs)[1].Path, "PATCH /repos/o/r/check-runs/5")
+
+	expected := `{"conclusion":"failure","output":{"annotations":[` +
+		`{"annotation_level":"warning","end_line":1,"message":"bad","path":"main.go","start_line":1}` +
+		`],"summary":"lint","title":"lint"},"status":"completed"}`
+	should.BeEqual(t, (*reqs)[1].Body, expected)
+
+	should.BeEqual(t, buf.String(), "::warning::check run \"lint\" was not finished by the script\n")
+
+	_, err = checkRunCall(th, cr, "finish", starlark.Tuple{starlark.String("success")}, nil)
+	must.NotBeZero(t, err)
+	should.BeEqual(t, err.Error(), "finish: check run is already finished")
+
+	buf.Reset()
+	a.Finish()
+	should.BeEqual(t, len(*reqs), 2)
+	should.BeEqual(t, buf.String(), "")
+}
@@ -0,0 +1,163 @@
+// Command starlark-githubactions-gen generates action metadata and documentation
+// from a Starlark script that calls githubactions.declare_action.
+//
+// Usage:
+//
+//	starlark-githubactions-gen [-action action.yml] [-docs README.md] [-check] script.star
+//
+// The script is executed in declaration-only mode: it stops after declare_action call,
+// and nothing is written to the workflow log or command files.
+//
+// The action metadata is written to the -action file.
+// If -docs is given, Markdown tables of inputs and outputs replace the content between
+//
+//	<!-- starlark-githubactions:start -->
+//	<!-- starlark-githubactions:end -->
+//
+// markers in that file.
+//
+// With -check, files are not written; instead, the command exits with a non-zero code
+// if they differ from the generated content. That is useful in CI.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	starlarkgithubactions "github.com/AlekSi/starlark-githubactions"
+)
+
+// Documentation markers.
+const (
+	startMarker = "<!-- starlark-githubactions:start -->"
+	endMarker   = "<!-- starlark-githubactions:end -->"
+)
+
+// generatedFile represents a file to generate.
+type generatedFile struct {
+	path     string
+	generate func(old []byte) ([]byte, error) // returns new content for the given old content
+	content  []byte
+	changed  bool
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs the command with the given arguments, and returns the exit code.
+func run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("starlark-githubactions-gen", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s [flags] script.star\n\nFlags:\n", fs.Name())
+		fs.PrintDefaults()
+	}
+
+	actionPath := fs.String("action", "action.yml", "action metadata `file` to generate")
+	docsPath := fs.String("docs", "", "Markdown `file` with markers to update with inputs and outputs tables")
+	check := fs.Bool("check", false, "check that files are up to date instead of writing them")
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	md, err := starlarkgithubactions.DeclaredActionMetadata(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	action, err := md.YAML()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	files := []generatedFile{{
+		path:     *actionPath,
+		generate: func([]byte) ([]byte, error) { return action, nil },
+	}}
+
+	if *docsPath != "" {
+		files = append(files, generatedFile{
+			path: *docsPath,
+			generate: func(old []byte) ([]byte, error) {
+				return replaceDocs(old, md.Markdown())
+			},
+		})
+	}
+
+	// generate all files first to avoid partial updates
+	for i, f := range files {
+		old, err := os.ReadFile(f.path)
+		if err != nil && !(errors.Is(err, os.ErrNotExist) && f.path == *actionPath) {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+
+		if files[i].content, err = f.generate(old); err != nil {
+			fmt.Fprintf(stderr, "%s: %s\n", f.path, err)
+			return 1
+		}
+
+		files[i].changed = !bytes.Equal(old, files[i].content)
+	}
+
+	code := 0
+	for _, f := range files {
+		if !f.changed {
+			continue
+		}
+
+		if *check {
+			fmt.Fprintf(stdout, "%s is out of date; run %s\n", f.path, fs.Name())
+			code = 1
+			continue
+		}
+
+		if err = os.WriteFile(f.path, f.content, 0o666); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+
+		fmt.Fprintf(stdout, "%s updated\n", f.path)
+	}
+
+	return code
+}
+
+// replaceDocs replaces the content between markers in the given document.
+func replaceDocs(doc []byte, content string) ([]byte, error) {
+	start := bytes.Index(doc, []byte(startMarker))
+	if start < 0 {
+		return nil, fmt.Errorf("marker %q not found", startMarker)
+	}
+
+	start += len(startMarker)
+
+	end := bytes.Index(doc[start:], []byte(endMarker))
+	if end < 0 {
+		return nil, fmt.Errorf("marker %q not found", endMarker)
+	}
+
+	end += start
+
+	var res bytes.Buffer
+	res.Write(doc[:start])
+	res.WriteString("\n\n")
+	res.WriteString(content)
+	res.WriteString("\n")
+	res.Write(doc[end:])
+
+	return res.Bytes(), nil
+}
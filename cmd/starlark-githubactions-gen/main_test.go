@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AlekSi/should"
+	"github.com/AlekSi/should/must"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	action := filepath.Join(dir, "action.yml")
+	docs := filepath.Join(dir, "README.md")
+
+	b, err := os.ReadFile("testdata/README.md")
+	must.BeZero(t, err)
+	must.BeZero(t, os.WriteFile(docs, b, 0o666))
+
+	run := func(args ...string) (int, string) {
+		t.Helper()
+
+		var stdout, stderr bytes.Buffer
+		args = append([]string{"-action", action, "-docs", docs}, args...)
+		code := run(append(args, "testdata/action.star"), &stdout, &stderr)
+		should.BeEqual(t, stderr.String(), "")
+		return code, stdout.String()
+	}
+
+	code, out := run("-check")
+	should.BeEqual(t, code, 1)
+	should.BeEqual(t, out, action+" is out of date; run starlark-githubactions-gen\n"+
+		docs+" is out of date; run starlark-githubactions-gen\n")
+
+	code, out = run()
+	should.BeEqual(t, code, 0)
+	should.BeEqual(t, out, action+" updated\n"+docs+" updated\n")
+
+	b, err = os.ReadFile(action)
+	must.BeZero(t, err)
+
+	expected := "# Code generated by starlark-githubactions-gen. DO NOT EDIT.\n\n" +
+		"name: Greet\n" +
+		"description: Greets someone.\n" +
+		"inputs:\n" +
+		"  shout:\n" +
+		"    description: Use | uppercase.\n" +
+		"    default: \"false\"\n" +
+		"  who:\n" +
+		"    description: Who to greet.\n" +
+		"    required: true\n" +
+		"    default: World\n" +
+		"outputs:\n" +
+		"  greeting:\n" +
+		"    description: Greeting message.\n" +
+		"runs:\n" +
+		"  steps:\n" +
+		"    - run: starlark-githubactions $GITHUB_ACTION_PATH/action.star\n" +
+		"      shell: bash\n" +
+		"  using: composite\n"
+	should.BeEqual(t, string(b), expected)
+
+	b, err = os.ReadFile(docs)
+	must.BeZero(t, err)
+
+	expected = "# Greet\n\n" +
+		"<!-- starlark-githubactions:start -->\n\n" +
+		"### Inputs\n\n" +
+		"| Name | Description | Required | Default |\n" +
+		"| ---- | ----------- | -------- | ------- |\n" +
+		"| `shout` | Use \\| uppercase. | no | `false` |\n" +
+		"| `who` | Who to greet. | yes | `World` |\n" +
+		"\n### Outputs\n\n" +
+		"| Name | Description |\n" +
+		"| ---- | ----------- |\n" +
+		"| `greeting` | Greeting message. |\n\n" +
+		"<!-- starlark-githubactions:end -->\n\n" +
+		"That's all.\n"
+	should.BeEqual(t, string(b), expected)
+
+	code, out = run("-check")
+	should.BeEqual(t, code, 0)
+	should.BeEqual(t, out, "")
+
+	must.BeZero(t, os.WriteFile(action, []byte("name: Greet\n"), 0o666))
+
+	code, out = run("-check")
+	should.BeEqual(t, code, 1)
+	should.BeEqual(t, out, action+" is out of date; run starlark-githubactions-gen\n")
+}
+
+func TestRunErrors(t *testing.T) {
+	dir := t.TempDir()
+	docs := filepath.Join(dir, "README.md")
+	must.BeZero(t, os.WriteFile(docs, []byte("# Greet\n"), 0o666))
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-action", filepath.Join(dir, "action.yml"), "-docs", docs, "testdata/action.star"}, &stdout, &stderr)
+	should.BeEqual(t, code, 1)
+	should.BeEqual(t, stderr.String(), docs+": marker \"<!-- starlark-githubactions:start -->\" not found\n")
+
+	// nothing is written on error
+	_, err := os.Stat(filepath.Join(dir, "action.yml"))
+	should.NotBeZero(t, err)
+
+	// action without runs
+	script := filepath.Join(dir, "action.star")
+	must.BeZero(t, os.WriteFile(script, []byte(`githubactions.declare_action(name = "X", description = "d")`+"\n"), 0o666))
+
+	stdout.Reset()
+	stderr.Reset()
+	code = run([]string{"-action", filepath.Join(dir, "action.yml"), script}, &stdout, &stderr)
+	should.BeEqual(t, code, 1)
+	should.BeEqual(t, stderr.String(), "YAML: runs.using is not set\n")
+
+	_, err = os.Stat(filepath.Join(dir, "action.yml"))
+	should.NotBeZero(t, err)
+
+	stdout.Reset()
+	stderr.Reset()
+	code = run(nil, &stdout, &stderr)
+	should.BeEqual(t, code, 2)
+}
@@ -0,0 +1,208 @@
+// Command starlark-githubactions runs a Starlark script as a GitHub Action.
+//
+// Usage:
+//
+//	starlark-githubactions [-function name] [-local [-event-name name] [-event file] [-inputs file]] script.star
+//
+// The script is executed with the githubactions module predeclared.
+// If -function is given, the named global function is called after the script is executed.
+// If the script fails (for example, by calling fail() or githubactions.fatal()),
+// the error with Starlark backtrace is reported as an error annotation,
+// and the command exits with a non-zero code.
+// Groups left open by the script are ended with a warning.
+//
+// If GITHUB_ACTION_PATH contains action.yml, inputs and outputs are checked against it;
+// see [starlarkgithubactions.WithActionMetadata].
+//
+// With -local, the script is executed in a synthetic GitHub Actions runner environment
+// with temporary command files, the given event, and inputs read from a YAML or JSON file.
+// Other environment variables (such as GITHUB_TOKEN and GITHUB_REPOSITORY) are taken from the process environment.
+// After execution, outputs, environment variables, path entries, state, and job summary are printed.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/sethvargo/go-githubactions"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+
+	starlarkgithubactions "github.com/AlekSi/starlark-githubactions"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr, os.Getenv))
+}
+
+// run runs the command with the given arguments and environment, and returns the exit code.
+func run(args []string, stdout, stderr io.Writer, getenv githubactions.GetenvFunc) int {
+	fs := flag.NewFlagSet("starlark-githubactions", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s [flags] script.star\n\nFlags:\n", fs.Name())
+		fs.PrintDefaults()
+	}
+
+	function := fs.String("function", "", "call the named global `function` after executing the script")
+	local := fs.Bool("local", false, "run in a synthetic runner environment and print a report")
+	eventName := fs.String("event-name", "", "event `name` for -local (default workflow_dispatch)")
+	eventPath := fs.String("event", "", "event payload JSON `file` for -local")
+	inputsPath := fs.String("inputs", "", "YAML or JSON `file` with inputs for -local")
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	if !*local && (*eventName != "" || *eventPath != "" || *inputsPath != "") {
+		fmt.Fprintln(stderr, "-event-name, -event, and -inputs require -local")
+		return 2
+	}
+
+	var opts []starlarkgithubactions.Option
+	var env *starlarkgithubactions.LocalEnv
+	if *local {
+		var err error
+		if env, err = newLocalEnv(*eventName, *eventPath, *inputsPath); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+
+		defer env.Close()
+
+		getenv = env.Getenv
+		opts = append(opts, starlarkgithubactions.WithEnviron(env.Environ), starlarkgithubactions.WithDeprecationWarnings())
+	}
+
+	if dir := getenv("GITHUB_ACTION_PATH"); dir != "" {
+		md, err := starlarkgithubactions.LoadActionMetadata(dir)
+		switch {
+		case err == nil:
+			opts = append(opts, starlarkgithubactions.WithActionMetadata(md))
+		case errors.Is(err, os.ErrNotExist):
+			// not an action with metadata
+		default:
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+	}
+
+	ga := githubactions.New(
+		githubactions.WithWriter(stdout),
+		githubactions.WithGetenv(getenv),
+	)
+
+	action := starlarkgithubactions.New(ga, opts...)
+	predeclared := starlark.StringDict{
+		"githubactions": starlarkgithubactions.NewModule("githubactions", action),
+	}
+
+	err := exec(fs.Arg(0), *function, predeclared, stdout)
+	action.Finish()
+
+	// fatal already reported the error
+	if err != nil && !action.FatalCalled() {
+		reportError(ga, err)
+	}
+
+	if env != nil {
+		if err := env.Report(stdout); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+	}
+
+	if err != nil {
+		return 1
+	}
+
+	return 0
+}
+
+// newLocalEnv creates a synthetic runner environment with the given event and inputs file.
+func newLocalEnv(eventName, eventPath, inputsPath string) (*starlarkgithubactions.LocalEnv, error) {
+	opts := &starlarkgithubactions.LocalOptions{
+		EventName: eventName,
+		EventPath: eventPath,
+	}
+
+	if eventPath != "" {
+		var err error
+		if opts.EventPath, err = filepath.Abs(eventPath); err != nil {
+			return nil, err
+		}
+	}
+
+	if inputsPath != "" {
+		var err error
+		if opts.Inputs, err = starlarkgithubactions.ReadInputs(inputsPath); err != nil {
+			return nil, err
+		}
+	}
+
+	return starlarkgithubactions.NewLocalEnv(opts)
+}
+
+// exec executes the script at the given path and calls the given function, if any.
+func exec(path, function string, predeclared starlark.StringDict, stdout io.Writer) error {
+	th := &starlark.Thread{
+		Name: path,
+		Print: func(th *starlark.Thread, msg string) {
+			fmt.Fprintln(stdout, msg)
+		},
+	}
+
+	globals, err := starlark.ExecFileOptions(&syntax.FileOptions{}, th, path, nil, predeclared)
+	if err != nil {
+		return err
+	}
+
+	if function == "" {
+		return nil
+	}
+
+	fn, ok := globals[function].(starlark.Callable)
+	if !ok {
+		return fmt.Errorf("%s: function %q is not defined", path, function)
+	}
+
+	_, err = starlark.Call(th, fn, nil, nil)
+	return err
+}
+
+// reportError reports the given error as an error annotation.
+//
+// For Starlark evaluation errors, the message includes the backtrace,
+// and the annotation points to the innermost call frame.
+func reportError(ga *githubactions.Action, err error) {
+	var evalErr *starlark.EvalError
+	if !errors.As(err, &evalErr) {
+		ga.Errorf("%s", err)
+		return
+	}
+
+	fields := make(map[string]string)
+	for i := len(evalErr.CallStack) - 1; i >= 0; i-- {
+		pos := evalErr.CallStack[i].Pos
+		if pos.Filename() == "" || pos.Filename() == "<builtin>" {
+			continue
+		}
+
+		fields["file"] = pos.Filename()
+		fields["line"] = strconv.Itoa(int(pos.Line))
+		fields["col"] = strconv.Itoa(int(pos.Col))
+		break
+	}
+
+	ga.WithFieldsMap(fields).Errorf("%s", evalErr.Backtrace())
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/AlekSi/should"
+)
+
+func TestRun(t *testing.T) {
+	getenv := func(string) string { return "" }
+
+	for name, tc := range map[string]struct {
+		args     []string
+		code     int
+		expected string
+	}{
+		"Script": {
+			args:     []string{"testdata/check.star"},
+			expected: "loaded\n",
+		},
+		"Function": {
+			args:     []string{"-function", "main", "testdata/check.star"},
+			expected: "loaded\nmain\n",
+		},
+		"Fail": {
+			args: []string{"-function", "bad", "testdata/check.star"},
+			code: 1,
+			expected: "loaded\n" +
+				"::error col=13,file=testdata/check.star,line=3::" +
+				"Traceback (most recent call last):%0A" +
+				"  testdata/check.star:10:10: in bad%0A" +
+				"  testdata/check.star:3:13: in check%0A" +
+				"Error in fail: fail: unexpected value: bad\n",
+		},
+		"Fatal": {
+			args:     []string{"-function", "stop", "testdata/check.star"},
+			code:     1,
+			expected: "loaded\n::error::stopping\n",
+		},
+		"UnclosedGroup": {
+			args:     []string{"-function", "unclosed", "testdata/check.star"},
+			expected: "loaded\n::group::unclosed\n::endgroup::\n::warning::1 group(s) were not ended by the script\n",
+		},
+		"UndefinedFunction": {
+			args:     []string{"-function", "missing", "testdata/check.star"},
+			code:     1,
+			expected: "loaded\n::error::testdata/check.star: function \"missing\" is not defined\n",
+		},
+		"Local": {
+			args: []string{
+				"-local", "-event-name", "pull_request", "-event", "testdata/event.json", "-inputs", "testdata/inputs.yml",
+				"testdata/local.star",
+			},
+			expected: "Outputs:\n" +
+				"  event = pull_request #42\n" +
+				"  greeting = Hello, World!\n" +
+				"Environment:\n" +
+				"  LINES = one\n" +
+				"    two\n" +
+				"State:\n" +
+				"  phase = main\n" +
+				"Path:\n" +
+				"  /opt/tool/bin\n" +
+				"Summary:\n" +
+				"  <h2>Done</h2>\n",
+		},
+		"LocalFlags": {
+			args: []string{"-inputs", "testdata/inputs.yml", "testdata/local.star"},
+			code: 2,
+		},
+		"NoScript": {
+			code: 2,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(tc.args, &stdout, &stderr, getenv)
+			should.BeEqual(t, code, tc.code)
+			should.BeEqual(t, stdout.String(), tc.expected)
+		})
+	}
+}
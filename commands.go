@@ -0,0 +1,167 @@
+package githubactions
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// commandFileMaxLine is the maximum length of a single line in command files.
+// The runner does not limit it, so the limit is set high.
+const commandFileMaxLine = 1 << 30
+
+// newCommandFileScanner returns a line scanner for the command file.
+func newCommandFileScanner(r io.Reader) *bufio.Scanner {
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, commandFileMaxLine)
+
+	return s
+}
+
+// FileCommand is a name/value pair written to a command file
+// (GITHUB_OUTPUT, GITHUB_ENV, or GITHUB_STATE).
+type FileCommand struct {
+	Name  string
+	Value string
+}
+
+// ParseFileCommands parses name/value pairs written to a command file
+// (GITHUB_OUTPUT, GITHUB_ENV, or GITHUB_STATE) and returns them in order.
+//
+// Both "name<<delimiter" heredoc format (used for multi-line values) and "name=value" format are supported;
+// like in the runner, the format is determined by whichever of "=" and "<<" comes first in the line.
+// See https://docs.github.com/en/actions/reference/workflows-and-actions/workflow-commands#multiline-strings.
+func ParseFileCommands(r io.Reader) ([]FileCommand, error) {
+	var res []FileCommand
+
+	s := newCommandFileScanner(r)
+
+	var n int
+	scan := func() (string, bool) {
+		if !s.Scan() {
+			return "", false
+		}
+
+		n++
+		return strings.TrimSuffix(s.Text(), "\r"), true
+	}
+
+	for {
+		line, ok := scan()
+		if !ok {
+			break
+		}
+
+		if line == "" {
+			continue
+		}
+
+		start := n
+
+		eq, heredoc := strings.Index(line, "="), strings.Index(line, "<<")
+		if heredoc >= 0 && (eq < 0 || heredoc < eq) {
+			name, delim := line[:heredoc], line[heredoc+2:]
+			if name == "" {
+				return nil, fmt.Errorf("ParseFileCommands: line %d: empty name", start)
+			}
+
+			if delim == "" {
+				return nil, fmt.Errorf("ParseFileCommands: line %d: %q: empty delimiter", start, name)
+			}
+
+			var value []string
+			var closed bool
+			for {
+				line, ok = scan()
+				if !ok {
+					break
+				}
+
+				if line == delim {
+					closed = true
+					break
+				}
+
+				value = append(value, line)
+			}
+
+			if !closed {
+				return nil, fmt.Errorf("ParseFileCommands: line %d: %q: delimiter %q not found", start, name, delim)
+			}
+
+			res = append(res, FileCommand{Name: name, Value: strings.Join(value, "\n")})
+			continue
+		}
+
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("ParseFileCommands: line %d: unexpected line %q", start, line)
+		}
+
+		if name == "" {
+			return nil, fmt.Errorf("ParseFileCommands: line %d: empty name", start)
+		}
+
+		res = append(res, FileCommand{Name: name, Value: value})
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("ParseFileCommands: %w", err)
+	}
+
+	return res, nil
+}
+
+// ReadFileCommands reads and parses the command file at the given path with [ParseFileCommands].
+func ReadFileCommands(path string) ([]FileCommand, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("ReadFileCommands: %w", err)
+	}
+
+	defer f.Close()
+
+	res, err := ParseFileCommands(f)
+	if err != nil {
+		return nil, fmt.Errorf("ReadFileCommands: %s: %w", path, err)
+	}
+
+	return res, nil
+}
+
+// ParsePathFile parses the GITHUB_PATH file and returns added paths in order.
+func ParsePathFile(r io.Reader) ([]string, error) {
+	var res []string
+
+	s := newCommandFileScanner(r)
+	for s.Scan() {
+		if line := strings.TrimSuffix(s.Text(), "\r"); line != "" {
+			res = append(res, line)
+		}
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("ParsePathFile: %w", err)
+	}
+
+	return res, nil
+}
+
+// ReadPathFile reads and parses the GITHUB_PATH file at the given path with [ParsePathFile].
+func ReadPathFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("ReadPathFile: %w", err)
+	}
+
+	defer f.Close()
+
+	res, err := ParsePathFile(f)
+	if err != nil {
+		return nil, fmt.Errorf("ReadPathFile: %s: %w", path, err)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,126 @@
+package githubactions
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/AlekSi/should"
+	"github.com/AlekSi/should/must"
+	"go.starlark.net/starlark"
+)
+
+func TestParseFileCommands(t *testing.T) {
+	for name, tc := range map[string]struct {
+		input    string
+		expected []FileCommand
+		err      string
+	}{
+		"Empty": {},
+		"Heredoc": {
+			input: "a<<EOF\nfirst\n\nsecond\nEOF\nb<<ghadelimiter_1\n\nghadelimiter_1\n",
+			expected: []FileCommand{
+				{Name: "a", Value: "first\n\nsecond"},
+				{Name: "b", Value: ""},
+			},
+		},
+		"Simple": {
+			input: "a=1\r\nb==\n\nc<<EOF\r\nx=y\r\nEOF\r\n",
+			expected: []FileCommand{
+				{Name: "a", Value: "1"},
+				{Name: "b", Value: "="},
+				{Name: "c", Value: "x=y"},
+			},
+		},
+		"EqualsFirst": {
+			input: "a=b<<c\nd<<EOF\ne=f\nEOF\n",
+			expected: []FileCommand{
+				{Name: "a", Value: "b<<c"},
+				{Name: "d", Value: "e=f"},
+			},
+		},
+		"HeredocFirst": {
+			input: "a<<b=c\nvalue\nb=c\n",
+			expected: []FileCommand{
+				{Name: "a", Value: "value"},
+			},
+		},
+		"Repeated": {
+			input: "a=1\na=2\n",
+			expected: []FileCommand{
+				{Name: "a", Value: "1"},
+				{Name: "a", Value: "2"},
+			},
+		},
+		"NoDelimiter": {
+			input: "a=1\nb<<EOF\nvalue\nEOF2\n",
+			err:   `ParseFileCommands: line 2: "b": delimiter "EOF" not found`,
+		},
+		"EmptyDelimiter": {
+			input: "b<<\nvalue\n",
+			err:   `ParseFileCommands: line 1: "b": empty delimiter`,
+		},
+		"EmptyName": {
+			input: "a=1\n=2\n",
+			err:   `ParseFileCommands: line 2: empty name`,
+		},
+		"Unexpected": {
+			input: "a<<EOF\nvalue\nEOF\nvalue\n",
+			err:   `ParseFileCommands: line 4: unexpected line "value"`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			actual, err := ParseFileCommands(strings.NewReader(tc.input))
+			if tc.err != "" {
+				must.NotBeZero(t, err)
+				should.BeEqual(t, err.Error(), tc.err)
+				return
+			}
+
+			must.BeZero(t, err)
+			should.BeEqual(t, actual, tc.expected)
+		})
+	}
+}
+
+func TestReadFileCommands(t *testing.T) {
+	var buf bytes.Buffer
+	th, m, getenv := setup(t, &buf, nil)
+
+	for _, name := range []string{"set_output", "set_env", "save_state"} {
+		for _, value := range []string{"first\nsecond", "third"} {
+			_, err := starlark.Call(th, m.Members[name], starlark.Tuple{starlark.String("NAME"), starlark.String(value)}, nil)
+			must.BeZero(t, err)
+		}
+	}
+
+	for _, env := range []string{"GITHUB_OUTPUT", "GITHUB_ENV", "GITHUB_STATE"} {
+		actual, err := ReadFileCommands(getenv(env))
+		must.BeZero(t, err)
+
+		expected := []FileCommand{
+			{Name: "NAME", Value: "first\nsecond"},
+			{Name: "NAME", Value: "third"},
+		}
+		should.BeEqual(t, actual, expected)
+	}
+
+	for _, p := range []string{"/first", "/second"} {
+		_, err := starlark.Call(th, m.Members["add_path"], starlark.Tuple{starlark.String(p)}, nil)
+		must.BeZero(t, err)
+	}
+
+	paths, err := ReadPathFile(getenv("GITHUB_PATH"))
+	must.BeZero(t, err)
+	should.BeEqual(t, paths, []string{"/first", "/second"})
+
+	should.BeEqual(t, buf.String(), "")
+}
+
+func TestParsePathFile(t *testing.T) {
+	long := "/" + strings.Repeat("a", 100_000)
+
+	actual, err := ParsePathFile(strings.NewReader("/first\r\n\n" + long + "\n"))
+	must.BeZero(t, err)
+	should.BeEqual(t, actual, []string{"/first", long})
+}
@@ -0,0 +1,166 @@
+package githubactions
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AlekSi/should"
+	"github.com/AlekSi/should/must"
+	"go.starlark.net/starlark"
+)
+
+// writeEvent writes the given event payload to a new file and returns its path.
+func writeEvent(tb testing.TB, event any) string {
+	tb.Helper()
+
+	b, err := json.Marshal(event)
+	must.BeZero(tb, err)
+
+	path := filepath.Join(tb.TempDir(), "event.json")
+	must.BeZero(tb, os.WriteFile(path, b, 0o666))
+
+	return path
+}
+
+// pushEvent returns a synthetic push event payload with the given number of commits.
+func pushEvent(commits int) map[string]any {
+	list := make([]any, commits)
+	for i := range list {
+		list[i] = map[string]any{
+			"id":        fmt.Sprintf("%040d", i),
+			"message":   strings.Repeat(fmt.Sprintf("Commit %d. ", i), 400),
+			"timestamp": "2026-01-01T00:00:00Z",
+			"author":    map[string]any{"name": "Author", "email": "author@example.com"},
+			"added":     []any{fmt.Sprintf("file%d.go", i)},
+			"removed":   []any{},
+			"modified":  []any{"go.mod", "go.sum"},
+		}
+	}
+
+	return map[string]any{
+		"ref":     "refs/heads/main",
+		"before":  strings.Repeat("0", 40),
+		"after":   strings.Repeat("f", 40),
+		"commits": list,
+	}
+}
+
+func TestContextEvent(t *testing.T) {
+	path := writeEvent(t, map[string]any{"number": 1})
+
+	var buf bytes.Buffer
+	th, m, _ := setupEnv(t, &buf, func(key string) string {
+		if key == "GITHUB_EVENT_PATH" {
+			return path
+		}
+		return ""
+	})
+
+	event := func(typed bool) starlark.Value {
+		t.Helper()
+
+		kwargs := []starlark.Tuple{{starlark.String("typed_event"), starlark.Bool(typed)}}
+		ctx, err := starlark.Call(th, m.Members["context"], nil, kwargs)
+		must.BeZero(t, err)
+
+		v, err := ctx.(starlark.HasAttrs).Attr("event")
+		must.BeZero(t, err)
+
+		v, err = v.(*lazyEvent).load()
+		must.BeZero(t, err)
+
+		return v
+	}
+
+	e1 := event(false)
+	should.BeEqual(t, e1.String(), `{"number": 1}`)
+	should.BeEqual(t, event(false) == e1, true)
+
+	o1 := event(true)
+	should.BeEqual(t, o1.Type(), "object")
+	should.BeEqual(t, event(true) == o1, true)
+
+	path = writeEvent(t, map[string]any{"number": 2})
+
+	ctx, err := starlark.Call(th, m.Members["context"], nil, nil)
+	must.BeZero(t, err)
+
+	v, err := ctx.(starlark.HasAttrs).Attr("event")
+	must.BeZero(t, err)
+
+	actual, err := encodeJSON(v, "")
+	must.BeZero(t, err)
+	should.BeEqual(t, actual, `{"number":2}`)
+
+	e2 := event(false)
+	should.BeEqual(t, e2.String(), `{"number": 2}`)
+	should.BeEqual(t, e2 == e1, false)
+	should.BeEqual(t, event(true) == o1, false)
+
+	// the payload is decoded only when the event is accessed
+	path = filepath.Join(t.TempDir(), "invalid.json")
+	must.BeZero(t, os.WriteFile(path, []byte("{"), 0o666))
+
+	ctx, err = starlark.Call(th, m.Members["context"], nil, nil)
+	must.BeZero(t, err)
+
+	_, err = ctx.(starlark.HasAttrs).Attr("run_id")
+	must.BeZero(t, err)
+
+	v, err = ctx.(starlark.HasAttrs).Attr("event")
+	must.BeZero(t, err)
+
+	_, _, err = v.(starlark.Mapping).Get(starlark.String("number"))
+	must.NotBeZero(t, err)
+	should.BeEqual(t, strings.Contains(err.Error(), "readEvent: "), true)
+
+	_, err = encodeJSON(ctx, "")
+	must.NotBeZero(t, err)
+	should.BeEqual(t, strings.HasPrefix(err.Error(), `starlarkToJSON: field "event": starlarkToJSON: Context: readEvent: `), true)
+
+	path = filepath.Join(t.TempDir(), "missing.json")
+
+	_, err = starlark.Call(th, m.Members["context"], nil, nil)
+	must.NotBeZero(t, err)
+	should.BeEqual(t, strings.HasPrefix(err.Error(), "Context: stat "), true)
+
+	should.BeEqual(t, buf.String(), "")
+}
+
+func BenchmarkContext(b *testing.B) {
+	path := writeEvent(b, pushEvent(500))
+
+	fi, err := os.Stat(path)
+	must.BeZero(b, err)
+	b.Logf("event size: %d bytes", fi.Size())
+
+	var buf bytes.Buffer
+	th, m, _ := setupEnv(b, &buf, func(key string) string {
+		if key == "GITHUB_EVENT_PATH" {
+			return path
+		}
+		return ""
+	})
+
+	b.Run("Cached", func(b *testing.B) {
+		for b.Loop() {
+			ctx, err := starlark.Call(th, m.Members["context"], nil, nil)
+			must.BeZero(b, err)
+
+			_, err = ctx.(starlark.HasAttrs).Attr("event")
+			must.BeZero(b, err)
+		}
+	})
+
+	b.Run("Uncached", func(b *testing.B) {
+		for b.Loop() {
+			_, err := readEvent(path)
+			must.BeZero(b, err)
+		}
+	})
+}
@@ -0,0 +1,346 @@
+package githubactions
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/sethvargo/go-githubactions"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+	"gopkg.in/yaml.v3"
+)
+
+// declarationOnlyReason is the thread cancellation reason used in declaration-only mode.
+const declarationOnlyReason = "action declared"
+
+// errDeclarationOnly is returned by builtins with side effects in declaration-only mode.
+var errDeclarationOnly = errors.New("not allowed before declare_action in declaration-only mode")
+
+// checkSideEffects returns [errDeclarationOnly] in declaration-only mode.
+// It should be called by builtins with side effects: running commands, writing files, and network requests.
+func (a *Action) checkSideEffects() error {
+	if a.declarationOnly {
+		return errDeclarationOnly
+	}
+
+	return nil
+}
+
+// DeclaredActionMetadata executes the script at the given path in declaration-only mode
+// and returns the action metadata declared with declare_action builtin.
+//
+// The script is executed with the githubactions module predeclared, empty environment,
+// and discarded output; execution stops after declare_action call.
+// Builtins with side effects (exec, fs writes, API requests, etc.) fail before that.
+func DeclaredActionMetadata(path string) (*ActionMetadata, error) {
+	ga := githubactions.New(
+		githubactions.WithWriter(io.Discard),
+		githubactions.WithGetenv(func(string) string { return "" }),
+	)
+
+	a := New(ga, WithEnviron(func() []string { return nil }))
+	a.declarationOnly = true
+
+	th := &starlark.Thread{
+		Name:  path,
+		Print: func(*starlark.Thread, string) {},
+	}
+
+	predeclared := starlark.StringDict{
+		"githubactions": NewModule("githubactions", a),
+	}
+
+	_, err := starlark.ExecFileOptions(&syntax.FileOptions{}, th, path, nil, predeclared)
+
+	a.metadataM.Lock()
+	md := a.declared
+	a.metadataM.Unlock()
+
+	if md != nil {
+		return md, nil
+	}
+
+	if err != nil {
+		var evalErr *starlark.EvalError
+		if errors.As(err, &evalErr) {
+			err = errors.New(evalErr.Backtrace())
+		}
+
+		return nil, fmt.Errorf("DeclaredActionMetadata: %w", err)
+	}
+
+	return nil, fmt.Errorf("DeclaredActionMetadata: %s does not call declare_action", path)
+}
+
+// declaredString returns the string field of the declaration dict.
+// Booleans and numbers are converted to strings like in YAML.
+func declaredString(v starlark.Value) (string, error) {
+	switch v := v.(type) {
+	case starlark.String:
+		return string(v), nil
+	case starlark.Bool, starlark.Int, starlark.Float:
+		return stringOrJSON(v)
+	default:
+		return "", fmt.Errorf("got %s, want string", v.Type())
+	}
+}
+
+// declaredInput converts the input declaration dict to [ActionInput].
+func declaredInput(v starlark.Value) (ActionInput, error) {
+	var res ActionInput
+
+	d, ok := v.(*starlark.Dict)
+	if !ok {
+		return res, fmt.Errorf("declaredInput: got %s, want dict", v.Type())
+	}
+
+	for _, item := range d.Items() {
+		k, _ := starlark.AsString(item[0])
+
+		var err error
+		switch k {
+		case "description":
+			res.Description, err = declaredString(item[1])
+		case "required":
+			b, ok := item[1].(starlark.Bool)
+			if !ok {
+				err = fmt.Errorf("got %s, want bool", item[1].Type())
+			}
+			res.Required = bool(b)
+		case "default":
+			res.Default, err = declaredString(item[1])
+		case "deprecation_message":
+			res.DeprecationMessage, err = declaredString(item[1])
+		default:
+			err = fmt.Errorf("unexpected key")
+		}
+
+		if err != nil {
+			return res, fmt.Errorf("declaredInput: %s: %w", item[0], err)
+		}
+	}
+
+	return res, nil
+}
+
+// declaredOutput converts the output declaration (a description string, or a dict) to [ActionOutput].
+func declaredOutput(v starlark.Value) (ActionOutput, error) {
+	var res ActionOutput
+
+	if s, ok := v.(starlark.String); ok {
+		res.Description = string(s)
+		return res, nil
+	}
+
+	d, ok := v.(*starlark.Dict)
+	if !ok {
+		return res, fmt.Errorf("declaredOutput: got %s, want string or dict", v.Type())
+	}
+
+	for _, item := range d.Items() {
+		k, _ := starlark.AsString(item[0])
+
+		var err error
+		switch k {
+		case "description":
+			res.Description, err = declaredString(item[1])
+		case "value":
+			res.Value, err = declaredString(item[1])
+		default:
+			err = fmt.Errorf("unexpected key")
+		}
+
+		if err != nil {
+			return res, fmt.Errorf("declaredOutput: %s: %w", item[0], err)
+		}
+	}
+
+	return res, nil
+}
+
+// DeclareAction declares action metadata:
+//
+//	declare_action(
+//	    name = "Greet",
+//	    description = "Greets someone.",
+//	    inputs = {"who": {"description": "Who to greet.", "required": True, "default": "World"}},
+//	    outputs = {"message": "Greeting message."},
+//	    runs = {"using": "composite", "steps": [...]},
+//	)
+//
+// Inputs are dicts with description, required, default, and deprecation_message keys.
+// Outputs are description strings or dicts with description and value keys.
+// Runs is optional for metadata enforcement, but required for generating action.yml (see [ActionMetadata.YAML]).
+//
+// If action metadata enforcement is not enabled yet (see [WithActionMetadata]), it is enabled with the declared metadata.
+// In declaration-only mode (see [DeclaredActionMetadata]), the script execution stops.
+func (a *Action) DeclareAction(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var name, description string
+	var inputs, outputs, runs *starlark.Dict
+	if err := starlark.UnpackArgs(
+		fn.Name(), args, kwargs,
+		"name", &name,
+		"description", &description,
+		"inputs??", &inputs,
+		"outputs??", &outputs,
+		"runs??", &runs,
+	); err != nil {
+		return nil, err
+	}
+
+	md := &ActionMetadata{
+		Name:        name,
+		Description: description,
+	}
+
+	if inputs != nil {
+		md.Inputs = make(map[string]ActionInput, inputs.Len())
+		for _, item := range inputs.Items() {
+			k, ok := starlark.AsString(item[0])
+			if !ok {
+				return nil, fmt.Errorf("%s: input name %s is not a string", fn.Name(), item[0])
+			}
+
+			in, err := declaredInput(item[1])
+			if err != nil {
+				return nil, fmt.Errorf("%s: input %q: %w", fn.Name(), k, err)
+			}
+
+			md.Inputs[k] = in
+		}
+	}
+
+	if outputs != nil {
+		md.Outputs = make(map[string]ActionOutput, outputs.Len())
+		for _, item := range outputs.Items() {
+			k, ok := starlark.AsString(item[0])
+			if !ok {
+				return nil, fmt.Errorf("%s: output name %s is not a string", fn.Name(), item[0])
+			}
+
+			out, err := declaredOutput(item[1])
+			if err != nil {
+				return nil, fmt.Errorf("%s: output %q: %w", fn.Name(), k, err)
+			}
+
+			md.Outputs[k] = out
+		}
+	}
+
+	if runs != nil {
+		// convert via JSON to get plain Go values
+		s, err := encodeJSON(runs, "")
+		if err != nil {
+			return nil, fmt.Errorf("%s: runs: %w", fn.Name(), err)
+		}
+
+		if err = yaml.Unmarshal([]byte(s), &md.Runs); err != nil {
+			return nil, fmt.Errorf("%s: runs: %w", fn.Name(), err)
+		}
+	}
+
+	a.metadataM.Lock()
+	defer a.metadataM.Unlock()
+
+	if a.declared != nil {
+		return nil, fmt.Errorf("%s: action is already declared", fn.Name())
+	}
+
+	a.declared = md
+
+	if a.declarationOnly {
+		th.Cancel(declarationOnlyReason)
+		return starlark.None, nil
+	}
+
+	if a.metadata == nil {
+		a.metadata = md
+	}
+
+	return starlark.None, nil
+}
+
+// YAML returns the action metadata file (action.yml) content.
+//
+// It returns an error if runs is not set, as such a file is not a valid action.
+func (md *ActionMetadata) YAML() ([]byte, error) {
+	if _, ok := md.Runs["using"]; !ok {
+		return nil, fmt.Errorf("YAML: runs.using is not set")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("# Code generated by starlark-githubactions-gen. DO NOT EDIT.\n\n")
+
+	e := yaml.NewEncoder(&buf)
+	e.SetIndent(2)
+
+	if err := e.Encode(md); err != nil {
+		return nil, fmt.Errorf("YAML: %w", err)
+	}
+
+	if err := e.Close(); err != nil {
+		return nil, fmt.Errorf("YAML: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// markdownCell escapes the text for a Markdown table cell.
+func markdownCell(text string) string {
+	text = strings.TrimSpace(text)
+	text = strings.ReplaceAll(text, "|", `\|`)
+	return strings.ReplaceAll(text, "\n", "<br>")
+}
+
+// Markdown returns Markdown tables of inputs and outputs.
+func (md *ActionMetadata) Markdown() string {
+	var res strings.Builder
+
+	res.WriteString("### Inputs\n\n")
+	if len(md.Inputs) == 0 {
+		res.WriteString("None.\n")
+	} else {
+		res.WriteString("| Name | Description | Required | Default |\n")
+		res.WriteString("| ---- | ----------- | -------- | ------- |\n")
+
+		for _, name := range slices.Sorted(maps.Keys(md.Inputs)) {
+			in := md.Inputs[name]
+
+			description := markdownCell(in.Description)
+			if in.DeprecationMessage != "" {
+				description += " **Deprecated:** " + markdownCell(in.DeprecationMessage)
+			}
+
+			required := "no"
+			if in.Required {
+				required = "yes"
+			}
+
+			def := ""
+			if in.Default != "" {
+				def = "`" + markdownCell(in.Default) + "`"
+			}
+
+			fmt.Fprintf(&res, "| `%s` | %s | %s | %s |\n", name, description, required, def)
+		}
+	}
+
+	res.WriteString("\n### Outputs\n\n")
+	if len(md.Outputs) == 0 {
+		res.WriteString("None.\n")
+	} else {
+		res.WriteString("| Name | Description |\n")
+		res.WriteString("| ---- | ----------- |\n")
+
+		for _, name := range slices.Sorted(maps.Keys(md.Outputs)) {
+			fmt.Fprintf(&res, "| `%s` | %s |\n", name, markdownCell(md.Outputs[name].Description))
+		}
+	}
+
+	return res.String()
+}
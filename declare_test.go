@@ -0,0 +1,155 @@
+package githubactions
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AlekSi/should"
+	"github.com/AlekSi/should/must"
+	"go.starlark.net/starlark"
+	"gopkg.in/yaml.v3"
+)
+
+func TestDeclaredActionMetadata(t *testing.T) {
+	md, err := DeclaredActionMetadata("testdata/declare.star")
+	must.BeZero(t, err)
+
+	expected, err := LoadActionMetadata("testdata/action")
+	must.BeZero(t, err)
+	should.BeEqual(t, md, expected)
+
+	b, err := md.YAML()
+	must.BeZero(t, err)
+
+	var actual ActionMetadata
+	must.BeZero(t, yaml.Unmarshal(b, &actual))
+	should.BeEqual(t, &actual, expected)
+
+	md.Runs = nil
+	_, err = md.YAML()
+	must.NotBeZero(t, err)
+	should.BeEqual(t, err.Error(), "YAML: runs.using is not set")
+
+	path := filepath.Join(t.TempDir(), "undeclared.star")
+	must.BeZero(t, os.WriteFile(path, []byte("x = 1\n"), 0o666))
+
+	_, err = DeclaredActionMetadata(path)
+	must.NotBeZero(t, err)
+	should.BeEqual(t, err.Error(), "DeclaredActionMetadata: "+path+" does not call declare_action")
+}
+
+func TestDeclaredActionMetadataSideEffects(t *testing.T) {
+	dir := t.TempDir()
+
+	for name, tc := range map[string]struct {
+		code string
+		err  string
+	}{
+		"Exec": {
+			code: `githubactions.exec(["touch", "touched"])`,
+			err:  "Error in exec: exec: not allowed before declare_action in declaration-only mode",
+		},
+		"FSWrite": {
+			code: `githubactions.fs.write("touched", "")`,
+			err:  "Error in write: write: not allowed before declare_action in declaration-only mode",
+		},
+		"API": {
+			code: `githubactions.api.get("user")`,
+			err:  "Error in get: get: apiRequest: not allowed before declare_action in declaration-only mode",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name+".star")
+			code := tc.code + "\n" + `githubactions.declare_action(name = "Touch", description = "Touches.")` + "\n"
+			must.BeZero(t, os.WriteFile(path, []byte(code), 0o666))
+
+			_, err := DeclaredActionMetadata(path)
+			must.NotBeZero(t, err)
+			should.BeEqual(t, strings.HasSuffix(err.Error(), tc.err), true)
+
+			_, err = os.Stat(filepath.Join(dir, "touched"))
+			should.BeEqual(t, os.IsNotExist(err), true)
+		})
+	}
+}
+
+func TestDeclareAction(t *testing.T) {
+	var buf bytes.Buffer
+	th, m, _ := setupEnv(t, &buf, func(string) string { return "" })
+
+	declare := func(kwargs ...starlark.Tuple) error {
+		_, err := starlark.Call(th, m.Members["declare_action"], nil, append([]starlark.Tuple{
+			{starlark.String("name"), starlark.String("Greet")},
+			{starlark.String("description"), starlark.String("Greets someone.")},
+		}, kwargs...))
+		return err
+	}
+
+	inputs := starlark.NewDict(1)
+	must.BeZero(t, inputs.SetKey(starlark.String("who"), newDict(starlark.StringDict{
+		"descr": starlark.String("Who to greet."),
+	})))
+
+	err := declare(starlark.Tuple{starlark.String("inputs"), inputs})
+	must.NotBeZero(t, err)
+	should.BeEqual(t, err.Error(), `declare_action: input "who": declaredInput: "descr": unexpected key`)
+
+	outputs := starlark.NewDict(1)
+	must.BeZero(t, outputs.SetKey(starlark.String("message"), starlark.MakeInt(42)))
+
+	err = declare(starlark.Tuple{starlark.String("outputs"), outputs})
+	must.NotBeZero(t, err)
+	should.BeEqual(t, err.Error(), `declare_action: output "message": declaredOutput: got int, want string or dict`)
+
+	inputs = starlark.NewDict(1)
+	must.BeZero(t, inputs.SetKey(starlark.String("who"), newDict(starlark.StringDict{
+		"description": starlark.String("Who to greet."),
+		"default":     starlark.String("World"),
+	})))
+	outputs = starlark.NewDict(1)
+	must.BeZero(t, outputs.SetKey(starlark.String("message"), starlark.String("Greeting message.")))
+
+	err = declare(starlark.Tuple{starlark.String("inputs"), inputs}, starlark.Tuple{starlark.String("outputs"), outputs})
+	must.BeZero(t, err)
+
+	err = declare()
+	must.NotBeZero(t, err)
+	should.BeEqual(t, err.Error(), "declare_action: action is already declared")
+
+	// declared metadata is enforced
+	res, err := starlark.Call(th, m.Members["get_input"], starlark.Tuple{starlark.String("who")}, nil)
+	must.BeZero(t, err)
+	should.BeEqual(t, res, starlark.String("World"))
+
+	_, err = starlark.Call(th, m.Members["get_input"], starlark.Tuple{starlark.String("whom")}, nil)
+	must.NotBeZero(t, err)
+	should.BeEqual(t, err.Error(), `get_input: input "whom" is not declared in action metadata`)
+
+	_, err = starlark.Call(th, m.Members["set_output"], starlark.Tuple{starlark.String("messages"), starlark.String("Hello")}, nil)
+	must.NotBeZero(t, err)
+	should.BeEqual(t, err.Error(), `set_output: output "messages" is not declared in action metadata`)
+}
+
+func TestMarkdown(t *testing.T) {
+	md, err := LoadActionMetadata("testdata/action")
+	must.BeZero(t, err)
+
+	md.Inputs["who"] = ActionInput{Description: "Who to greet:\nname | team.", Required: true, Default: "World"}
+
+	expected := "### Inputs\n\n" +
+		"| Name | Description | Required | Default |\n" +
+		"| ---- | ----------- | -------- | ------- |\n" +
+		"| `greeting` | Greeting to use. | no |  |\n" +
+		"| `name` | Old name of the who input. **Deprecated:** Use who instead. | no |  |\n" +
+		"| `who` | Who to greet:<br>name \\| team. | yes | `World` |\n" +
+		"\n### Outputs\n\n" +
+		"| Name | Description |\n" +
+		"| ---- | ----------- |\n" +
+		"| `message` | Greeting message. |\n"
+	should.BeEqual(t, md.Markdown(), expected)
+
+	should.BeEqual(t, (&ActionMetadata{}).Markdown(), "### Inputs\n\nNone.\n\n### Outputs\n\nNone.\n")
+}
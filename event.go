@@ -0,0 +1,172 @@
+package githubactions
+
+import (
+	"fmt"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+)
+
+// lazyEvent is the event field of the context returned by [Action.Context].
+//
+// It behaves like the event payload (a dict, or [*Object] for typed events) for reading,
+// but the payload is read and decoded only on the first access to it, and then cached by [Action].
+// Its type is "event"; it is equal only to other events with equal payloads.
+// Operations that can't return errors behave like for an empty payload if it can't be loaded;
+// others return the loading error.
+type lazyEvent struct {
+	a      *Action
+	path   string
+	object bool
+}
+
+// check interfaces
+var (
+	_ starlark.IterableMapping = (*lazyEvent)(nil)
+	_ starlark.Sequence        = (*lazyEvent)(nil)
+	_ starlark.HasAttrs        = (*lazyEvent)(nil)
+	_ starlark.Comparable      = (*lazyEvent)(nil)
+)
+
+// load returns the frozen event payload.
+func (e *lazyEvent) load() (starlark.Value, error) {
+	return e.a.loadEvent(e.path, e.object)
+}
+
+// mapping returns the event payload as a mapping.
+func (e *lazyEvent) mapping() (starlark.IterableMapping, error) {
+	v, err := e.load()
+	if err != nil {
+		return nil, err
+	}
+
+	m, ok := v.(starlark.IterableMapping)
+	if !ok {
+		return nil, fmt.Errorf("event payload is %s, want object", v.Type())
+	}
+
+	return m, nil
+}
+
+// String implements [starlark.Value].
+func (e *lazyEvent) String() string {
+	v, err := e.load()
+	if err != nil {
+		return fmt.Sprintf("<event: %s>", err)
+	}
+
+	return v.String()
+}
+
+// Type implements [starlark.Value].
+//
+// It differs from the payload type, as Starlark compares values of the same type
+// assuming they have the same Go type.
+func (e *lazyEvent) Type() string {
+	return "event"
+}
+
+// Freeze implements [starlark.Value].
+func (e *lazyEvent) Freeze() {
+	// the payload is always frozen
+}
+
+// Truth implements [starlark.Value].
+func (e *lazyEvent) Truth() starlark.Bool {
+	v, err := e.load()
+	if err != nil {
+		return starlark.False
+	}
+
+	return v.Truth()
+}
+
+// Hash implements [starlark.Value].
+func (e *lazyEvent) Hash() (uint32, error) {
+	v, err := e.load()
+	if err != nil {
+		return 0, err
+	}
+
+	return v.Hash()
+}
+
+// Get implements [starlark.Mapping].
+func (e *lazyEvent) Get(k starlark.Value) (starlark.Value, bool, error) {
+	m, err := e.mapping()
+	if err != nil {
+		return nil, false, err
+	}
+
+	return m.Get(k)
+}
+
+// Iterate implements [starlark.Iterable].
+func (e *lazyEvent) Iterate() starlark.Iterator {
+	m, err := e.mapping()
+	if err != nil {
+		return starlark.NewDict(0).Iterate()
+	}
+
+	return m.Iterate()
+}
+
+// Items implements [starlark.IterableMapping].
+func (e *lazyEvent) Items() []starlark.Tuple {
+	m, err := e.mapping()
+	if err != nil {
+		return nil
+	}
+
+	return m.Items()
+}
+
+// Len implements [starlark.Sequence].
+func (e *lazyEvent) Len() int {
+	return len(e.Items())
+}
+
+// Attr implements [starlark.HasAttrs].
+func (e *lazyEvent) Attr(name string) (starlark.Value, error) {
+	v, err := e.load()
+	if err != nil {
+		return nil, err
+	}
+
+	h, ok := v.(starlark.HasAttrs)
+	if !ok {
+		return nil, nil
+	}
+
+	return h.Attr(name)
+}
+
+// AttrNames implements [starlark.HasAttrs].
+func (e *lazyEvent) AttrNames() []string {
+	v, err := e.load()
+	if err != nil {
+		return nil
+	}
+
+	h, ok := v.(starlark.HasAttrs)
+	if !ok {
+		return nil
+	}
+
+	return h.AttrNames()
+}
+
+// CompareSameType implements [starlark.Comparable].
+func (e *lazyEvent) CompareSameType(op syntax.Token, y starlark.Value, depth int) (bool, error) {
+	xv, err := e.load()
+	if err != nil {
+		return false, err
+	}
+
+	yv, err := y.(*lazyEvent).load()
+	if err != nil {
+		return false, err
+	}
+
+	return starlark.CompareDepth(op, xv, yv, depth)
+}
@@ -0,0 +1,170 @@
+package githubactions
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+// execWaitDelay is the time to wait for the output of a killed subprocess.
+const execWaitDelay = time.Second
+
+// Exec runs the command with the given arguments and returns a struct
+// with exit_code, stdout, and stderr fields.
+//
+// The environment variables from env dict are added to the current environment.
+// If check is true (the default), it fails if the command exits with a non-zero code.
+// If timeout (in seconds) is given, the command is killed after it.
+// If group is given, the command and its output (with secrets masked) are logged in a group with that title.
+//
+// The command is also killed if the thread is canceled with [Action.Cancel]
+// (for example, by fatal), or if the thread context (see [ContextKey]) is canceled.
+func (a *Action) Exec(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := a.checkSideEffects(); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	var argv starlark.Iterable
+	var env *starlark.Dict
+	var cwd, group string
+	var stdin, timeout starlark.Value
+	check := true
+	if err := starlark.UnpackArgs(
+		fn.Name(), args, kwargs,
+		"argv", &argv,
+		"env??", &env,
+		"cwd??", &cwd,
+		"stdin??", &stdin,
+		"timeout??", &timeout,
+		"check?", &check,
+		"group??", &group,
+	); err != nil {
+		return nil, err
+	}
+
+	var cmdArgs []string
+	for v := range starlark.Elements(argv) {
+		s, ok := starlark.AsString(v)
+		if !ok {
+			return nil, fmt.Errorf("%s: argv[%d] is %s, want string", fn.Name(), len(cmdArgs), v.Type())
+		}
+
+		cmdArgs = append(cmdArgs, s)
+	}
+
+	if len(cmdArgs) == 0 {
+		return nil, fmt.Errorf("%s: argv must not be empty", fn.Name())
+	}
+
+	ctx, done := a.cancelableContext(th)
+	defer done()
+
+	var d time.Duration
+	if timeout != nil {
+		f, ok := starlark.AsFloat(timeout)
+		if !ok || f <= 0 {
+			return nil, fmt.Errorf("%s: timeout must be a positive number of seconds, got %s", fn.Name(), timeout)
+		}
+
+		d = time.Duration(f * float64(time.Second))
+
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, cmdArgs[0], cmdArgs[1:]...)
+	cmd.Dir = cwd
+	cmd.WaitDelay = execWaitDelay
+
+	cmd.Env = a.environ()
+	if env != nil {
+		for _, item := range env.Items() {
+			k, ok1 := starlark.AsString(item[0])
+			v, ok2 := starlark.AsString(item[1])
+			if !ok1 || !ok2 {
+				return nil, fmt.Errorf("%s: env must be a dict of strings, got %s: %s", fn.Name(), item[0].Type(), item[1].Type())
+			}
+
+			cmd.Env = append(cmd.Env, k+"="+v)
+		}
+	}
+
+	if stdin != nil {
+		s, ok := starlark.AsString(stdin)
+		if !ok {
+			return nil, fmt.Errorf("%s: for parameter stdin: got %s, want string", fn.Name(), stdin.Type())
+		}
+
+		cmd.Stdin = strings.NewReader(s)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if group != "" {
+		a.group(group)
+		defer a.endGroup()
+
+		a.a.Infof("%s", a.mask("$ "+strings.Join(cmdArgs, " ")))
+	}
+
+	err := cmd.Run()
+
+	if group != "" {
+		for _, out := range []string{stdout.String(), stderr.String()} {
+			if out = strings.TrimSuffix(out, "\n"); out != "" {
+				a.a.Infof("%s", a.mask(out))
+			}
+		}
+	}
+
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return nil, fmt.Errorf("%s: %s: timed out after %s", fn.Name(), cmdArgs[0], d)
+	case ctx.Err() != nil:
+		return nil, fmt.Errorf("%s: %s: %w", fn.Name(), cmdArgs[0], context.Cause(ctx))
+	}
+
+	exitCode := 0
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			// avoid "exec: exec:" prefix
+			var execErr *exec.Error
+			if errors.As(err, &execErr) {
+				err = execErr.Err
+			}
+
+			return nil, fmt.Errorf("%s: %s: %w", fn.Name(), cmdArgs[0], err)
+		}
+
+		exitCode = exitErr.ExitCode()
+	}
+
+	if check && exitCode != 0 {
+		msg := fmt.Sprintf("%s: %s exited with code %d", fn.Name(), cmdArgs[0], exitCode)
+		if s := strings.TrimSpace(stderr.String()); s != "" {
+			msg += ":\n" + a.mask(s)
+		}
+
+		return nil, errors.New(msg)
+	}
+
+	res := starlarkstruct.FromStringDict(starlark.String("exec_result"), starlark.StringDict{
+		"exit_code": starlark.MakeInt(exitCode),
+		"stdout":    starlark.String(stdout.String()),
+		"stderr":    starlark.String(stderr.String()),
+	})
+
+	res.Freeze()
+	return res, nil
+}
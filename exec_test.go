@@ -0,0 +1,126 @@
+package githubactions
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/AlekSi/should"
+	"github.com/AlekSi/should/must"
+	"github.com/sethvargo/go-githubactions"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+// argv returns a Starlark list of the given strings.
+func argv(args ...string) *starlark.List {
+	elems := make([]starlark.Value, len(args))
+	for i, a := range args {
+		elems[i] = starlark.String(a)
+	}
+
+	return starlark.NewList(elems)
+}
+
+func TestExec(t *testing.T) {
+	var buf bytes.Buffer
+	th, m, _ := setup(t, &buf, nil)
+
+	kwargs := []starlark.Tuple{{starlark.String("check"), starlark.False}}
+	res, err := starlark.Call(th, m.Members["exec"], starlark.Tuple{argv("sh", "-c", "echo out; echo err >&2; exit 3")}, kwargs)
+	must.BeZero(t, err)
+
+	s := res.(*starlarkstruct.Struct)
+	v, _ := s.Attr("exit_code")
+	should.BeEqual(t, v, starlark.MakeInt(3))
+	v, _ = s.Attr("stdout")
+	should.BeEqual(t, v, starlark.String("out\n"))
+	v, _ = s.Attr("stderr")
+	should.BeEqual(t, v, starlark.String("err\n"))
+
+	_, err = starlark.Call(th, m.Members["exec"], starlark.Tuple{argv("sh", "-c", "echo err >&2; exit 3")}, nil)
+	must.NotBeZero(t, err)
+	should.BeEqual(t, err.Error(), "exec: sh exited with code 3:\nerr")
+
+	dir := t.TempDir()
+	env := starlark.NewDict(1)
+	must.BeZero(t, env.SetKey(starlark.String("FOO"), starlark.String("bar")))
+
+	kwargs = []starlark.Tuple{
+		{starlark.String("env"), env},
+		{starlark.String("cwd"), starlark.String(dir)},
+		{starlark.String("stdin"), starlark.String("input")},
+	}
+	res, err = starlark.Call(th, m.Members["exec"], starlark.Tuple{argv("sh", "-c", "echo $FOO; pwd; cat")}, kwargs)
+	must.BeZero(t, err)
+
+	v, _ = res.(*starlarkstruct.Struct).Attr("stdout")
+	should.BeEqual(t, v, starlark.String("bar\n"+dir+"\ninput"))
+
+	_, err = starlark.Call(th, m.Members["exec"], starlark.Tuple{argv("no-such-command-for-test")}, nil)
+	must.NotBeZero(t, err)
+	should.BeEqual(t, err.Error(), "exec: no-such-command-for-test: executable file not found in $PATH")
+
+	res, err = starlark.Call(th, m.Members["exec"], starlark.Tuple{starlark.Tuple{starlark.String("echo"), starlark.String("tuple")}}, nil)
+	must.BeZero(t, err)
+
+	v, _ = res.(*starlarkstruct.Struct).Attr("stdout")
+	should.BeEqual(t, v, starlark.String("tuple\n"))
+
+	_, err = starlark.Call(th, m.Members["exec"], starlark.Tuple{starlark.Tuple{starlark.String("echo"), starlark.MakeInt(1)}}, nil)
+	must.NotBeZero(t, err)
+	should.BeEqual(t, err.Error(), "exec: argv[1] is int, want string")
+
+	_, err = starlark.Call(th, m.Members["exec"], starlark.Tuple{starlark.Tuple{}}, nil)
+	must.NotBeZero(t, err)
+	should.BeEqual(t, err.Error(), "exec: argv must not be empty")
+
+	should.BeEqual(t, buf.String(), "")
+}
+
+func TestExecGroup(t *testing.T) {
+	var buf bytes.Buffer
+	th, m, _ := setup(t, &buf, nil)
+
+	_, err := starlark.Call(th, m.Members["add_mask"], starlark.Tuple{starlark.String("s3cr3t")}, nil)
+	must.BeZero(t, err)
+
+	kwargs := []starlark.Tuple{{starlark.String("group"), starlark.String("Echo")}}
+	res, err := starlark.Call(th, m.Members["exec"], starlark.Tuple{argv("echo", "token", "s3cr3t")}, kwargs)
+	must.BeZero(t, err)
+
+	v, _ := res.(*starlarkstruct.Struct).Attr("stdout")
+	should.BeEqual(t, v, starlark.String("token s3cr3t\n"))
+
+	expected := "::add-mask::s3cr3t\n" +
+		"::group::Echo\n" +
+		"$ echo token ***\n" +
+		"token ***\n" +
+		"::endgroup::\n"
+	should.BeEqual(t, buf.String(), expected)
+}
+
+func TestExecTimeout(t *testing.T) {
+	var buf bytes.Buffer
+	th, m, _ := setup(t, &buf, nil)
+
+	kwargs := []starlark.Tuple{{starlark.String("timeout"), starlark.Float(0.1)}}
+	_, err := starlark.Call(th, m.Members["exec"], starlark.Tuple{argv("sleep", "10")}, kwargs)
+	must.NotBeZero(t, err)
+	should.BeEqual(t, err.Error(), "exec: sleep: timed out after 100ms")
+}
+
+func TestExecCancel(t *testing.T) {
+	var buf bytes.Buffer
+	a := New(githubactions.New(githubactions.WithWriter(&buf)))
+	m := NewModule(t.Name(), a)
+	th := &starlark.Thread{Name: t.Name()}
+
+	time.AfterFunc(100*time.Millisecond, func() { a.Cancel(th, "stop") })
+
+	start := time.Now()
+	_, err := starlark.Call(th, m.Members["exec"], starlark.Tuple{argv("sleep", "10")}, nil)
+	must.NotBeZero(t, err)
+	should.BeEqual(t, err.Error(), "exec: sleep: stop")
+	should.BeEqual(t, time.Since(start) < 5*time.Second, true)
+}
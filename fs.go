@@ -0,0 +1,613 @@
+package githubactions
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+// WithUnrestrictedFS allows fs module builtins to access paths outside of the workspace.
+func WithUnrestrictedFS() Option {
+	return func(a *Action) {
+		a.unrestrictedFS = true
+	}
+}
+
+// newFSModule constructs the fs Starlark module for the given [Action].
+//
+// Relative paths are resolved against the workspace (GITHUB_WORKSPACE, or the current directory if it is not set).
+// Paths outside of the workspace are refused unless [WithUnrestrictedFS] option is used.
+func newFSModule(a *Action) *starlarkstruct.Module {
+	m := &starlarkstruct.Module{
+		Name:    "fs",
+		Members: make(starlark.StringDict),
+	}
+
+	for _, b := range []*starlark.Builtin{
+		starlark.NewBuiltin("read", a.FSRead),
+		starlark.NewBuiltin("write", a.FSWrite),
+		starlark.NewBuiltin("append", a.FSAppend),
+		starlark.NewBuiltin("exists", a.FSExists),
+		starlark.NewBuiltin("glob", a.FSGlob),
+		starlark.NewBuiltin("mkdir", a.FSMkdir),
+		starlark.NewBuiltin("remove", a.FSRemove),
+		starlark.NewBuiltin("stat", a.FSStat),
+	} {
+		m.Members[b.Name()] = b
+	}
+
+	return m
+}
+
+// workspace returns the absolute path of the workspace directory.
+func (a *Action) workspace() (string, error) {
+	ws := a.a.Getenv("GITHUB_WORKSPACE")
+	if ws == "" {
+		ws = "."
+	}
+
+	ws, err := filepath.Abs(ws)
+	if err != nil {
+		return "", fmt.Errorf("workspace: %w", err)
+	}
+
+	return ws, nil
+}
+
+// maxSymlinks is the maximum number of dangling symlinks followed by [evalSymlinks].
+const maxSymlinks = 255
+
+// evalSymlinks is like [filepath.EvalSymlinks], but allows the path (and its parents) to not exist.
+// Dangling symlinks are resolved to their (missing) targets.
+func evalSymlinks(p string) (string, error) {
+	var rest []string
+	var links int
+	for {
+		res, err := filepath.EvalSymlinks(p)
+		if err == nil {
+			return filepath.Join(append([]string{res}, rest...)...), nil
+		}
+
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+
+		if fi, lerr := os.Lstat(p); lerr == nil && fi.Mode()&fs.ModeSymlink != 0 {
+			if links++; links > maxSymlinks {
+				return "", fmt.Errorf("evalSymlinks: too many links in %s", p)
+			}
+
+			target, err := os.Readlink(p)
+			if err != nil {
+				return "", err
+			}
+
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(p), target)
+			}
+
+			p = filepath.Clean(target)
+			continue
+		}
+
+		parent := filepath.Dir(p)
+		if parent == p {
+			return "", err
+		}
+
+		rest = append([]string{filepath.Base(p)}, rest...)
+		p = parent
+	}
+}
+
+// fsPath returns the absolute path for the given name,
+// checking that it is within the workspace unless [WithUnrestrictedFS] option is used.
+func (a *Action) fsPath(name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("fsPath: empty path")
+	}
+
+	ws, err := a.workspace()
+	if err != nil {
+		return "", fmt.Errorf("fsPath: %w", err)
+	}
+
+	p := name
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(ws, p)
+	}
+	p = filepath.Clean(p)
+
+	if a.unrestrictedFS {
+		return p, nil
+	}
+
+	// resolve symlinks to prevent escaping the workspace via them
+	realWS, err := evalSymlinks(ws)
+	if err != nil {
+		return "", fmt.Errorf("fsPath: %w", err)
+	}
+
+	realP, err := evalSymlinks(p)
+	if err != nil {
+		return "", fmt.Errorf("fsPath: %w", err)
+	}
+
+	rel, err := filepath.Rel(realWS, realP)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("fsPath: %q is outside of the workspace %q", name, ws)
+	}
+
+	return p, nil
+}
+
+// fsDir is the directory used by fs builtins: [*os.Root] for the workspace,
+// or [osDir] if [WithUnrestrictedFS] option is used.
+type fsDir interface {
+	Close() error
+	FS() fs.FS
+	Lstat(name string) (fs.FileInfo, error)
+	Mkdir(name string, perm fs.FileMode) error
+	OpenFile(name string, flag int, perm fs.FileMode) (*os.File, error)
+	Remove(name string) error
+	Stat(name string) (fs.FileInfo, error)
+}
+
+// osDir is an unrestricted [fsDir] implemented with [os] functions.
+type osDir string
+
+// Close implements [fsDir].
+func (d osDir) Close() error {
+	return nil
+}
+
+// FS implements [fsDir].
+func (d osDir) FS() fs.FS {
+	return os.DirFS(string(d))
+}
+
+// Lstat implements [fsDir].
+func (d osDir) Lstat(name string) (fs.FileInfo, error) {
+	return os.Lstat(filepath.Join(string(d), name))
+}
+
+// Mkdir implements [fsDir].
+func (d osDir) Mkdir(name string, perm fs.FileMode) error {
+	return os.Mkdir(filepath.Join(string(d), name), perm)
+}
+
+// OpenFile implements [fsDir].
+func (d osDir) OpenFile(name string, flag int, perm fs.FileMode) (*os.File, error) {
+	return os.OpenFile(filepath.Join(string(d), name), flag, perm)
+}
+
+// Remove implements [fsDir].
+func (d osDir) Remove(name string) error {
+	return os.Remove(filepath.Join(string(d), name))
+}
+
+// Stat implements [fsDir].
+func (d osDir) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(filepath.Join(string(d), name))
+}
+
+// check interfaces
+var (
+	_ fsDir = (*os.Root)(nil)
+	_ fsDir = osDir("")
+)
+
+// fsRoot checks the given name with fsPath and returns the opened directory
+// with the path relative to it.
+//
+// The directory is the workspace opened with [os.OpenRoot],
+// so that symlinks can't be used to escape it, even if they are changed after the check.
+// If [WithUnrestrictedFS] option is used, it is the file system root without restrictions.
+// The caller should close the directory.
+func (a *Action) fsRoot(name string) (fsDir, string, error) {
+	p, err := a.fsPath(name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if a.unrestrictedFS {
+		dir := filepath.VolumeName(p) + string(filepath.Separator)
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return nil, "", fmt.Errorf("fsRoot: %w", err)
+		}
+
+		return osDir(dir), rel, nil
+	}
+
+	ws, err := a.workspace()
+	if err != nil {
+		return nil, "", fmt.Errorf("fsRoot: %w", err)
+	}
+
+	rel, err := filepath.Rel(ws, p)
+	if err != nil {
+		return nil, "", fmt.Errorf("fsRoot: %w", err)
+	}
+
+	root, err := os.OpenRoot(ws)
+	if err != nil {
+		return nil, "", fmt.Errorf("fsRoot: %w", err)
+	}
+
+	return root, rel, nil
+}
+
+// fsPathArg unpacks a single path argument and returns the opened directory with the path relative to it;
+// see fsRoot.
+func (a *Action) fsPathArg(fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (fsDir, string, error) {
+	var name string
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "path", &name); err != nil {
+		return nil, "", err
+	}
+
+	root, rel, err := a.fsRoot(name)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	return root, rel, nil
+}
+
+// rootMkdirAll is like [os.MkdirAll] within the directory.
+func rootMkdirAll(root fsDir, name string, perm fs.FileMode) error {
+	var p string
+	for _, e := range strings.Split(name, string(filepath.Separator)) {
+		p = filepath.Join(p, e)
+
+		err := root.Mkdir(p, perm)
+		if err == nil {
+			continue
+		}
+
+		if !errors.Is(err, fs.ErrExist) {
+			return err
+		}
+
+		fi, err := root.Stat(p)
+		if err != nil {
+			return err
+		}
+
+		if !fi.IsDir() {
+			return &fs.PathError{Op: "mkdir", Path: p, Err: syscall.ENOTDIR}
+		}
+	}
+
+	return nil
+}
+
+// rootRemoveAll is like [os.RemoveAll] within the directory, but returns an error if the path does not exist.
+// Symlinks are removed, not followed.
+func rootRemoveAll(root fsDir, name string) error {
+	fi, err := root.Lstat(name)
+	if err != nil {
+		return err
+	}
+
+	if fi.IsDir() {
+		entries, err := fs.ReadDir(root.FS(), filepath.ToSlash(name))
+		if err != nil {
+			return err
+		}
+
+		for _, e := range entries {
+			if err = rootRemoveAll(root, filepath.Join(name, e.Name())); err != nil {
+				return err
+			}
+		}
+	}
+
+	return root.Remove(name)
+}
+
+// FSRead returns the content of the file.
+func (a *Action) FSRead(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	root, rel, err := a.fsPathArg(fn, args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+
+	defer root.Close()
+
+	b, err := fs.ReadFile(root.FS(), filepath.ToSlash(rel))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	return starlark.String(b), nil
+}
+
+// fsWrite writes the content to the file, truncating or appending.
+func (a *Action) fsWrite(fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple, flag int) error {
+	if err := a.checkSideEffects(); err != nil {
+		return fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	var name, content string
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "path", &name, "content", &content); err != nil {
+		return err
+	}
+
+	root, rel, err := a.fsRoot(name)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	defer root.Close()
+
+	f, err := root.OpenFile(rel, os.O_WRONLY|os.O_CREATE|flag, 0o644)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	if _, err = f.WriteString(content); err != nil {
+		f.Close()
+		return fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	if err = f.Close(); err != nil {
+		return fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	return nil
+}
+
+// FSWrite writes the content to the file, creating or truncating it.
+func (a *Action) FSWrite(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := a.fsWrite(fn, args, kwargs, os.O_TRUNC); err != nil {
+		return nil, err
+	}
+
+	return starlark.None, nil
+}
+
+// FSAppend appends the content to the file, creating it if needed.
+func (a *Action) FSAppend(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := a.fsWrite(fn, args, kwargs, os.O_APPEND); err != nil {
+		return nil, err
+	}
+
+	return starlark.None, nil
+}
+
+// FSExists returns true if the file or directory exists.
+func (a *Action) FSExists(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	root, rel, err := a.fsPathArg(fn, args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+
+	defer root.Close()
+
+	_, err = root.Stat(rel)
+	switch {
+	case err == nil:
+		return starlark.True, nil
+	case errors.Is(err, fs.ErrNotExist):
+		return starlark.False, nil
+	default:
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+}
+
+// globMatch reports whether the slash-separated path segments match the pattern segments.
+// The "**" pattern segment matches zero or more path segments.
+func globMatch(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if globMatch(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if len(segments) == 0 {
+		return false
+	}
+
+	if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+		return false
+	}
+
+	return globMatch(pattern[1:], segments[1:])
+}
+
+// FSGlob returns a sorted list of paths matching the pattern.
+//
+// The pattern uses [path.Match] syntax with slash separators,
+// and the "**" path element matches zero or more directories.
+// For relative patterns, returned paths are relative to the workspace.
+func (a *Action) FSGlob(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var pattern string
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "pattern", &pattern); err != nil {
+		return nil, err
+	}
+
+	segments := strings.Split(filepath.ToSlash(pattern), "/")
+	for _, s := range segments {
+		if _, err := path.Match(s, ""); err != nil {
+			return nil, fmt.Errorf("%s: invalid pattern %q: %w", fn.Name(), pattern, err)
+		}
+	}
+
+	// walk from the longest prefix without meta characters
+	var i int
+	for i < len(segments)-1 && !strings.ContainsAny(segments[i], `*?[\`) {
+		i++
+	}
+
+	prefix := strings.Join(segments[:i], "/")
+	if prefix == "" && strings.HasPrefix(pattern, "/") {
+		prefix = "/"
+	}
+
+	base := prefix
+	if base == "" {
+		base = "."
+	}
+
+	root, rel, err := a.fsRoot(filepath.FromSlash(base))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	defer root.Close()
+
+	rel = filepath.ToSlash(rel)
+
+	var res []string
+	err = fs.WalkDir(root.FS(), rel, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+
+			return err
+		}
+
+		// skip symlinks pointing outside
+		if d.Type()&fs.ModeSymlink != 0 {
+			if _, err = root.Stat(filepath.FromSlash(p)); err != nil {
+				return nil
+			}
+		}
+
+		var relSegments []string
+		switch {
+		case p == rel:
+		case rel == ".":
+			relSegments = strings.Split(p, "/")
+		default:
+			relSegments = strings.Split(strings.TrimPrefix(p, rel+"/"), "/")
+		}
+
+		if globMatch(segments[i:], relSegments) {
+			res = append(res, path.Join(append([]string{prefix}, relSegments...)...))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	slices.Sort(res)
+
+	elems := make([]starlark.Value, len(res))
+	for i, p := range res {
+		elems[i] = starlark.String(p)
+	}
+
+	return starlark.NewList(elems), nil
+}
+
+// FSMkdir creates the directory with all parents, if needed.
+func (a *Action) FSMkdir(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := a.checkSideEffects(); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	root, rel, err := a.fsPathArg(fn, args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+
+	defer root.Close()
+
+	if err = rootMkdirAll(root, rel, 0o755); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	return starlark.None, nil
+}
+
+// FSRemove removes the file or empty directory.
+// If recursive is true, it removes the directory with all its content.
+// It does nothing if the path does not exist.
+func (a *Action) FSRemove(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := a.checkSideEffects(); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	var name string
+	var recursive bool
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "path", &name, "recursive?", &recursive); err != nil {
+		return nil, err
+	}
+
+	p, err := a.fsPath(name)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	if ws, _ := a.workspace(); p == ws {
+		return nil, fmt.Errorf("%s: refusing to remove the workspace", fn.Name())
+	}
+
+	root, rel, err := a.fsRoot(name)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	defer root.Close()
+
+	if recursive {
+		err = rootRemoveAll(root, rel)
+	} else {
+		err = root.Remove(rel)
+	}
+
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	return starlark.None, nil
+}
+
+// FSStat returns a struct with name, size, mode, is_dir, and mtime (Unix time in seconds) fields.
+func (a *Action) FSStat(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	root, rel, err := a.fsPathArg(fn, args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+
+	defer root.Close()
+
+	fi, err := root.Stat(rel)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	res := starlarkstruct.FromStringDict(starlark.String("stat"), starlark.StringDict{
+		"name":   starlark.String(fi.Name()),
+		"size":   starlark.MakeInt64(fi.Size()),
+		"mode":   starlark.MakeInt(int(fi.Mode().Perm())),
+		"is_dir": starlark.Bool(fi.IsDir()),
+		"mtime":  starlark.Float(float64(fi.ModTime().UnixNano()) / 1e9),
+	})
+
+	res.Freeze()
+	return res, nil
+}
@@ -0,0 +1,259 @@
+package githubactions
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AlekSi/should"
+	"github.com/AlekSi/should/must"
+	"github.com/sethvargo/go-githubactions"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+// fsCall calls the fs module builtin with the given positional arguments.
+func fsCall(tb testing.TB, th *starlark.Thread, m *starlarkstruct.Module, name string, args ...starlark.Value) (starlark.Value, error) {
+	tb.Helper()
+
+	fs := m.Members["fs"].(*starlarkstruct.Module)
+	return starlark.Call(th, fs.Members[name], args, nil)
+}
+
+func TestFS(t *testing.T) {
+	ws := t.TempDir()
+
+	var buf bytes.Buffer
+	th, m, _ := setupEnv(t, &buf, func(key string) string {
+		if key == "GITHUB_WORKSPACE" {
+			return ws
+		}
+		return ""
+	})
+
+	res, err := fsCall(t, th, m, "exists", starlark.String("a/b/c.txt"))
+	must.BeZero(t, err)
+	should.BeEqual(t, res, starlark.False)
+
+	_, err = fsCall(t, th, m, "write", starlark.String("a/b/c.txt"), starlark.String("foo"))
+	must.NotBeZero(t, err)
+
+	_, err = fsCall(t, th, m, "mkdir", starlark.String("a/b"))
+	must.BeZero(t, err)
+
+	_, err = fsCall(t, th, m, "write", starlark.String("a/b/c.txt"), starlark.String("foo"))
+	must.BeZero(t, err)
+
+	_, err = fsCall(t, th, m, "append", starlark.String(filepath.Join(ws, "a/b/c.txt")), starlark.String("bar"))
+	must.BeZero(t, err)
+
+	res, err = fsCall(t, th, m, "read", starlark.String("a/b/c.txt"))
+	must.BeZero(t, err)
+	should.BeEqual(t, res, starlark.String("foobar"))
+
+	res, err = fsCall(t, th, m, "exists", starlark.String("a/b/c.txt"))
+	must.BeZero(t, err)
+	should.BeEqual(t, res, starlark.True)
+
+	res, err = fsCall(t, th, m, "stat", starlark.String("a/b/c.txt"))
+	must.BeZero(t, err)
+
+	s := res.(*starlarkstruct.Struct)
+	v, _ := s.Attr("name")
+	should.BeEqual(t, v, starlark.String("c.txt"))
+	v, _ = s.Attr("size")
+	should.BeEqual(t, v, starlark.MakeInt(6))
+	v, _ = s.Attr("mode")
+	should.BeEqual(t, v, starlark.MakeInt(0o644))
+	v, _ = s.Attr("is_dir")
+	should.BeEqual(t, v, starlark.False)
+
+	must.BeZero(t, os.WriteFile(filepath.Join(ws, "a", "d.txt"), nil, 0o644))
+	must.BeZero(t, os.WriteFile(filepath.Join(ws, "e.txt"), nil, 0o644))
+	must.BeZero(t, os.WriteFile(filepath.Join(ws, "a", "b", "f.go"), nil, 0o644))
+
+	for pattern, expected := range map[string][]string{
+		"*.txt":      {"e.txt"},
+		"**/*.txt":   {"a/b/c.txt", "a/d.txt", "e.txt"},
+		"a/**/*.txt": {"a/b/c.txt", "a/d.txt"},
+		"a/*":        {"a/b", "a/d.txt"},
+		"a/b/*.go":   {"a/b/f.go"},
+		"x/**":       nil,
+	} {
+		t.Run(pattern, func(t *testing.T) {
+			res, err := fsCall(t, th, m, "glob", starlark.String(pattern))
+			must.BeZero(t, err)
+
+			var actual []string
+			for v := range starlark.Elements(res.(*starlark.List)) {
+				actual = append(actual, string(v.(starlark.String)))
+			}
+			should.BeEqual(t, actual, expected)
+		})
+	}
+
+	_, err = fsCall(t, th, m, "remove", starlark.String("a"))
+	must.NotBeZero(t, err)
+
+	_, err = fsCall(t, th, m, "remove", starlark.String("a"), starlark.True)
+	must.BeZero(t, err)
+
+	res, err = fsCall(t, th, m, "exists", starlark.String("a"))
+	must.BeZero(t, err)
+	should.BeEqual(t, res, starlark.False)
+
+	_, err = fsCall(t, th, m, "remove", starlark.String("."), starlark.True)
+	must.NotBeZero(t, err)
+	should.BeEqual(t, err.Error(), "remove: refusing to remove the workspace")
+
+	should.BeEqual(t, buf.String(), "")
+}
+
+func TestFSOutside(t *testing.T) {
+	dir := t.TempDir()
+	ws := filepath.Join(dir, "ws")
+	must.BeZero(t, os.Mkdir(ws, 0o755))
+	must.BeZero(t, os.WriteFile(filepath.Join(dir, "secret.txt"), []byte("secret"), 0o644))
+	must.BeZero(t, os.Symlink(dir, filepath.Join(ws, "link")))
+
+	getenv := func(key string) string {
+		if key == "GITHUB_WORKSPACE" {
+			return ws
+		}
+		return ""
+	}
+
+	var buf bytes.Buffer
+	th, m, _ := setupEnv(t, &buf, getenv)
+
+	for _, p := range []string{
+		"../secret.txt",
+		filepath.Join(dir, "secret.txt"),
+		"link/secret.txt",
+		"link/new.txt",
+	} {
+		_, err := fsCall(t, th, m, "read", starlark.String(p))
+		must.NotBeZero(t, err)
+		should.BeEqual(t, err.Error(), `read: fsPath: "`+p+`" is outside of the workspace "`+ws+`"`)
+	}
+
+	res, err := fsCall(t, th, m, "glob", starlark.String("**/*.txt"))
+	must.BeZero(t, err)
+	should.BeEqual(t, res.(*starlark.List).Len(), 0)
+
+	th, m, _ = setupEnv(t, &buf, getenv, WithUnrestrictedFS())
+
+	res, err = fsCall(t, th, m, "read", starlark.String("link/secret.txt"))
+	must.BeZero(t, err)
+	should.BeEqual(t, res, starlark.String("secret"))
+
+	res, err = fsCall(t, th, m, "read", starlark.String("../secret.txt"))
+	must.BeZero(t, err)
+	should.BeEqual(t, res, starlark.String("secret"))
+}
+
+func TestFSSymlinkEscape(t *testing.T) {
+	dir := t.TempDir()
+	ws := filepath.Join(dir, "ws")
+	must.BeZero(t, os.Mkdir(ws, 0o755))
+
+	outside := filepath.Join(dir, "outside.txt")
+	must.BeZero(t, os.WriteFile(outside, []byte("original"), 0o644))
+
+	links := map[string]string{
+		"existing":     outside,
+		"relative":     "../outside.txt",
+		"dangling":     filepath.Join(dir, "missing.txt"),
+		"dangling-rel": "../missing.txt",
+		"chain":        "dangling",
+		"dir":          filepath.Join(dir, "missing-dir"),
+	}
+	for name, target := range links {
+		must.BeZero(t, os.Symlink(target, filepath.Join(ws, name)))
+	}
+
+	// symlinks within the workspace are allowed
+	must.BeZero(t, os.Symlink("inside.txt", filepath.Join(ws, "inside")))
+
+	var buf bytes.Buffer
+	th, m, _ := setupEnv(t, &buf, func(key string) string {
+		if key == "GITHUB_WORKSPACE" {
+			return ws
+		}
+		return ""
+	})
+
+	for name := range links {
+		t.Run(name, func(t *testing.T) {
+			for _, b := range []string{"write", "append"} {
+				_, err := fsCall(t, th, m, b, starlark.String(name), starlark.String("x"))
+				must.NotBeZero(t, err)
+				should.BeEqual(t, err.Error(), b+`: fsPath: "`+name+`" is outside of the workspace "`+ws+`"`)
+			}
+
+			for _, b := range []string{"read", "exists", "stat", "mkdir"} {
+				_, err := fsCall(t, th, m, b, starlark.String(name))
+				must.NotBeZero(t, err)
+				should.BeEqual(t, err.Error(), b+`: fsPath: "`+name+`" is outside of the workspace "`+ws+`"`)
+			}
+
+			_, err := fsCall(t, th, m, "mkdir", starlark.String(name+"/sub"))
+			must.NotBeZero(t, err)
+		})
+	}
+
+	b, err := os.ReadFile(outside)
+	must.BeZero(t, err)
+	should.BeEqual(t, string(b), "original")
+
+	for _, p := range []string{"missing.txt", "missing-dir"} {
+		_, err = os.Lstat(filepath.Join(dir, p))
+		should.NotBeZero(t, err)
+	}
+
+	_, err = fsCall(t, th, m, "write", starlark.String("inside"), starlark.String("x"))
+	must.BeZero(t, err)
+
+	b, err = os.ReadFile(filepath.Join(ws, "inside.txt"))
+	must.BeZero(t, err)
+	should.BeEqual(t, string(b), "x")
+
+	// symlinks are removed, not followed
+	must.BeZero(t, os.Mkdir(filepath.Join(ws, "sub"), 0o755))
+	must.BeZero(t, os.Symlink(dir, filepath.Join(ws, "sub", "up")))
+
+	_, err = fsCall(t, th, m, "remove", starlark.String("sub"), starlark.True)
+	must.BeZero(t, err)
+
+	_, err = os.Stat(outside)
+	must.BeZero(t, err)
+
+	should.BeEqual(t, buf.String(), "")
+}
+
+func TestFSRoot(t *testing.T) {
+	dir := t.TempDir()
+	ws := filepath.Join(dir, "ws")
+	must.BeZero(t, os.Mkdir(ws, 0o755))
+
+	a := New(githubactions.New(githubactions.WithGetenv(func(key string) string {
+		if key == "GITHUB_WORKSPACE" {
+			return ws
+		}
+		return ""
+	})))
+
+	root, rel, err := a.fsRoot("link/x.txt")
+	must.BeZero(t, err)
+	defer root.Close()
+
+	// the symlink is created after the check, but still can't be used to escape
+	must.BeZero(t, os.Symlink(dir, filepath.Join(ws, "link")))
+
+	_, err = root.OpenFile(rel, os.O_WRONLY|os.O_CREATE, 0o644)
+	must.NotBeZero(t, err)
+
+	_, err = os.Stat(filepath.Join(dir, "x.txt"))
+	should.NotBeZero(t, err)
+}
@@ -36,20 +36,47 @@ func NewModule(name string, a *Action) *starlarkstruct.Module {
 
 		starlark.NewBuiltin("group", a.Group),
 		starlark.NewBuiltin("end_group", a.EndGroup),
+		starlark.NewBuiltin("with_group", a.WithGroup),
 
 		starlark.NewBuiltin("get_input", a.GetInput),
+		starlark.NewBuiltin("get_bool_input", a.GetBoolInput),
+		starlark.NewBuiltin("get_int_input", a.GetIntInput),
+		starlark.NewBuiltin("get_float_input", a.GetFloatInput),
+		starlark.NewBuiltin("get_multiline_input", a.GetMultilineInput),
 		starlark.NewBuiltin("set_output", a.SetOutput),
+		starlark.NewBuiltin("action_metadata", a.ActionMetadata),
+		starlark.NewBuiltin("declare_action", a.DeclareAction),
 
 		starlark.NewBuiltin("save_state", a.SaveState),
+		starlark.NewBuiltin("get_state", a.GetState),
+		starlark.NewBuiltin("state", a.State),
 
 		starlark.NewBuiltin("set_env", a.SetEnv),
 		starlark.NewBuiltin("add_path", a.AddPath),
 
 		starlark.NewBuiltin("context", a.Context),
+		starlark.NewBuiltin("dig", a.Dig),
+
+		starlark.NewBuiltin("get_id_token", a.GetIDToken),
+
+		starlark.NewBuiltin("exec", a.Exec),
+
+		starlark.NewBuiltin("graphql", a.GraphQL),
+		starlark.NewBuiltin("graphql_paginate", a.GraphQLPaginate),
+		starlark.NewBuiltin("set_commit_status", a.SetCommitStatus),
+
+		starlark.NewBuiltin("from_json", a.FromJSON),
+		starlark.NewBuiltin("to_json", a.ToJSON),
 	} {
 		m.Members[b.Name()] = b
 	}
 
+	m.Members["summary"] = newSummaryModule(a)
+	m.Members["fs"] = newFSModule(a)
+	m.Members["api"] = newAPIModule(a)
+	m.Members["pr"] = newPRModule(a)
+	m.Members["checks"] = newChecksModule(a)
+
 	return m
 }
 
@@ -8,6 +8,7 @@ require (
 	github.com/AlekSi/should v0.0.0-20260101121228-0345e893611a
 	github.com/sethvargo/go-githubactions v1.3.2
 	go.starlark.net v0.0.0-20260102030733-3fee463870c9
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require golang.org/x/sys v0.0.0-20220715151400-c0bba94af5f8 // indirect
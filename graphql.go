@@ -0,0 +1,209 @@
+package githubactions
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"go.starlark.net/starlark"
+)
+
+// graphqlURL returns GitHub GraphQL API URL.
+func (a *Action) graphqlURL() string {
+	if u := a.a.Getenv("GITHUB_GRAPHQL_URL"); u != "" {
+		return u
+	}
+
+	return "https://api.github.com/graphql"
+}
+
+// graphqlErrors formats GraphQL errors from the response.
+func graphqlErrors(errs *starlark.List) error {
+	var msgs []string
+	for e := range starlark.Elements(errs) {
+		d, ok := e.(*starlark.Dict)
+		if !ok {
+			msgs = append(msgs, e.String())
+			continue
+		}
+
+		msg := e.String()
+		if v, found, _ := d.Get(starlark.String("message")); found {
+			if s, ok := starlark.AsString(v); ok {
+				msg = s
+			}
+		}
+
+		if v, found, _ := d.Get(starlark.String("path")); found {
+			if l, ok := v.(*starlark.List); ok && l.Len() > 0 {
+				path := make([]string, 0, l.Len())
+				for p := range starlark.Elements(l) {
+					if s, ok := starlark.AsString(p); ok {
+						path = append(path, s)
+					} else {
+						path = append(path, p.String())
+					}
+				}
+
+				msg = strings.Join(path, ".") + ": " + msg
+			}
+		}
+
+		msgs = append(msgs, msg)
+	}
+
+	return fmt.Errorf("graphqlErrors: %s", strings.Join(msgs, "; "))
+}
+
+// graphqlRequest sends GraphQL query with the given variables (a dict or nil)
+// and returns the data field of the response.
+func (a *Action) graphqlRequest(ctx context.Context, query string, variables starlark.Value) (starlark.Value, error) {
+	body := starlark.NewDict(2)
+	_ = body.SetKey(starlark.String("query"), starlark.String(query))
+
+	if variables != nil && variables != starlark.None {
+		_ = body.SetKey(starlark.String("variables"), variables)
+	}
+
+	resp, err := a.apiRequest(ctx, http.MethodPost, a.graphqlURL(), body, nil)
+	if err != nil {
+		return nil, fmt.Errorf("graphqlRequest: %w", err)
+	}
+
+	res, ok := resp.value.(*starlark.Dict)
+	if !ok {
+		return nil, fmt.Errorf("graphqlRequest: unexpected response %s", resp.value.Type())
+	}
+
+	if errs, _, _ := res.Get(starlark.String("errors")); errs != starlark.None {
+		l, ok := errs.(*starlark.List)
+		if !ok {
+			return nil, fmt.Errorf("graphqlRequest: unexpected errors %s", errs)
+		}
+
+		if l.Len() > 0 {
+			return nil, fmt.Errorf("graphqlRequest: %w", graphqlErrors(l))
+		}
+	}
+
+	data, _, _ := res.Get(starlark.String("data"))
+	return data, nil
+}
+
+// GraphQL sends the GraphQL query with the given variables dict and returns the data field of the response.
+//
+// Requests are authenticated like api module requests and sent to GITHUB_GRAPHQL_URL (graphql_url of the context).
+// GraphQL errors are returned as Starlark errors with their paths.
+func (a *Action) GraphQL(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var query string
+	var variables *starlark.Dict
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "query", &query, "variables??", &variables); err != nil {
+		return nil, err
+	}
+
+	var vars starlark.Value
+	if variables != nil {
+		vars = variables
+	}
+
+	ctx, done := a.cancelableContext(th)
+	defer done()
+
+	res, err := a.graphqlRequest(ctx, query, vars)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	return res, nil
+}
+
+// graphqlConnection returns the connection object at the given dotted path of data.
+func graphqlConnection(data starlark.Value, path string) (*starlark.Dict, error) {
+	v := data
+	for _, key := range strings.Split(path, ".") {
+		d, ok := v.(*starlark.Dict)
+		if !ok {
+			return nil, fmt.Errorf("graphqlConnection: %s: %s is not an object", path, v.Type())
+		}
+
+		var found bool
+		v, found, _ = d.Get(starlark.String(key))
+		if !found {
+			return nil, fmt.Errorf("graphqlConnection: %s: %q not found", path, key)
+		}
+	}
+
+	res, ok := v.(*starlark.Dict)
+	if !ok {
+		return nil, fmt.Errorf("graphqlConnection: %s: %s is not an object", path, v.Type())
+	}
+
+	return res, nil
+}
+
+// GraphQLPaginate sends the GraphQL query repeatedly, following the cursor of the connection at the given dotted path,
+// and returns a frozen list of all nodes (or edges, if the connection has no nodes field).
+//
+// The query should accept $cursor variable and use it as the connection's after argument;
+// the connection should select pageInfo { hasNextPage endCursor }.
+func (a *Action) GraphQLPaginate(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var query, path string
+	var variables *starlark.Dict
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "query", &query, "path", &path, "variables??", &variables); err != nil {
+		return nil, err
+	}
+
+	vars := starlark.NewDict(1)
+	if variables != nil {
+		for _, item := range variables.Items() {
+			_ = vars.SetKey(item[0], item[1])
+		}
+	}
+
+	ctx, done := a.cancelableContext(th)
+	defer done()
+
+	var elems []starlark.Value
+	for {
+		data, err := a.graphqlRequest(ctx, query, vars)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+		}
+
+		conn, err := graphqlConnection(data, path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+		}
+
+		items, found, _ := conn.Get(starlark.String("nodes"))
+		if !found {
+			items, _, _ = conn.Get(starlark.String("edges"))
+		}
+
+		l, ok := items.(*starlark.List)
+		if !ok {
+			return nil, fmt.Errorf("%s: %s: connection has no nodes or edges", fn.Name(), path)
+		}
+
+		elems = slices.AppendSeq(elems, starlark.Elements(l))
+
+		pageInfo, err := graphqlConnection(conn, "pageInfo")
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", fn.Name(), path, err)
+		}
+
+		next, _, _ := pageInfo.Get(starlark.String("hasNextPage"))
+		cursor, _, _ := pageInfo.Get(starlark.String("endCursor"))
+		if next != starlark.True || cursor == starlark.None {
+			break
+		}
+
+		_ = vars.SetKey(starlark.String("cursor"), cursor)
+	}
+
+	res := starlark.NewList(elems)
+	res.Freeze()
+	return res, nil
+}
@@ -0,0 +1,137 @@
+package githubactions
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/AlekSi/should"
+	"github.com/AlekSi/should/must"
+	"go.starlark.net/starlark"
+)
+
+// graphqlRequestBody is a GraphQL request received by the test server.
+type graphqlRequestBody struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables"`
+}
+
+// newGraphQLServer starts a fake GraphQL server that responds using the given function,
+// and returns getenv function for it.
+func newGraphQLServer(tb testing.TB, respond func(req *graphqlRequestBody) any) (*[]graphqlRequestBody, func(string) string) {
+	tb.Helper()
+
+	var reqs []graphqlRequestBody
+	var apiReqs *[]apiRequestLog
+	apiReqs, getenv := newAPIServer(tb, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		should.BeEqual(tb, r.Method+" "+r.URL.Path, "POST /graphql")
+
+		// the body was already read by the API server
+		var req graphqlRequestBody
+		must.BeZero(tb, json.Unmarshal([]byte((*apiReqs)[len(*apiReqs)-1].Body), &req))
+		reqs = append(reqs, req)
+
+		writeJSON(tb, w, http.StatusOK, respond(&req))
+	}))
+
+	return &reqs, func(key string) string {
+		if key == "GITHUB_GRAPHQL_URL" {
+			return getenv("GITHUB_API_URL") + "/graphql"
+		}
+
+		return getenv(key)
+	}
+}
+
+func TestGraphQL(t *testing.T) {
+	reqs, getenv := newGraphQLServer(t, func(req *graphqlRequestBody) any {
+		if req.Variables["name"] == "missing" {
+			return map[string]any{
+				"data": map[string]any{"repository": nil},
+				"errors": []any{
+					map[string]any{
+						"type":    "NOT_FOUND",
+						"path":    []any{"repository"},
+						"message": "Could not resolve to a Repository with the name 'o/missing'.",
+					},
+					map[string]any{"message": "Something else."},
+				},
+			}
+		}
+
+		return map[string]any{"data": map[string]any{"repository": map[string]any{"stargazerCount": 7}}}
+	})
+
+	var buf bytes.Buffer
+	th, m, _ := setupEnv(t, &buf, getenv)
+
+	const query = `query($owner: String!, $name: String!) { repository(owner: $owner, name: $name) { stargazerCount } }`
+
+	vars := starlark.NewDict(2)
+	must.BeZero(t, vars.SetKey(starlark.String("owner"), starlark.String("o")))
+	must.BeZero(t, vars.SetKey(starlark.String("name"), starlark.String("r")))
+
+	res, err := starlark.Call(th, m.Members["graphql"], starlark.Tuple{starlark.String(query), vars}, nil)
+	must.BeZero(t, err)
+	should.BeEqual(t, res.String(), `{"repository": {"stargazerCount": 7}}`)
+
+	must.BeZero(t, vars.SetKey(starlark.String("name"), starlark.String("missing")))
+
+	_, err = starlark.Call(th, m.Members["graphql"], starlark.Tuple{starlark.String(query), vars}, nil)
+	must.NotBeZero(t, err)
+	should.BeEqual(t, err.Error(), "graphql: graphqlRequest: graphqlErrors: "+
+		"repository: Could not resolve to a Repository with the name 'o/missing'.; Something else.")
+
+	expected := []graphqlRequestBody{
+		{Query: query, Variables: map[string]any{"owner": "o", "name": "r"}},
+		{Query: query, Variables: map[string]any{"owner": "o", "name": "missing"}},
+	}
+	should.BeEqual(t, *reqs, expected)
+
+	should.BeEqual(t, buf.String(), "")
+}
+
+func TestGraphQLPaginate(t *testing.T) {
+	reqs, getenv := newGraphQLServer(t, func(req *graphqlRequestBody) any {
+		var nodes []any
+		pageInfo := map[string]any{"hasNextPage": true, "endCursor": "c1"}
+
+		switch req.Variables["cursor"] {
+		case nil:
+			nodes = []any{map[string]any{"number": 1}, map[string]any{"number": 2}}
+		case "c1":
+			nodes = []any{map[string]any{"number": 3}}
+			pageInfo = map[string]any{"hasNextPage": false, "endCursor": "c2"}
+		}
+
+		return map[string]any{"data": map[string]any{"repository": map[string]any{"pullRequests": map[string]any{
+			"nodes":    nodes,
+			"pageInfo": pageInfo,
+		}}}}
+	})
+
+	var buf bytes.Buffer
+	th, m, _ := setupEnv(t, &buf, getenv)
+
+	const query = `query($cursor: String) { repository(owner: "o", name: "r") { ` +
+		`pullRequests(first: 2, after: $cursor) { nodes { number } pageInfo { hasNextPage endCursor } } } }`
+
+	res, err := starlark.Call(th, m.Members["graphql_paginate"], starlark.Tuple{
+		starlark.String(query), starlark.String("repository.pullRequests"),
+	}, nil)
+	must.BeZero(t, err)
+	should.BeEqual(t, res.String(), `[{"number": 1}, {"number": 2}, {"number": 3}]`)
+
+	expected := []graphqlRequestBody{
+		{Query: query, Variables: map[string]any{}},
+		{Query: query, Variables: map[string]any{"cursor": "c1"}},
+	}
+	should.BeEqual(t, *reqs, expected)
+
+	_, err = starlark.Call(th, m.Members["graphql_paginate"], starlark.Tuple{
+		starlark.String(query), starlark.String("repository.issues"),
+	}, nil)
+	must.NotBeZero(t, err)
+	should.BeEqual(t, err.Error(), `graphql_paginate: graphqlConnection: repository.issues: "issues" not found`)
+}
@@ -0,0 +1,232 @@
+package githubactions
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+// maxJSONDepth is the maximum nesting depth of Starlark values encoded as JSON.
+// It protects against cyclic values.
+const maxJSONDepth = 1000
+
+// errJSONDepth is returned by [starlarkToJSON] for values nested deeper than [maxJSONDepth].
+// It is not wrapped on each level to keep the message short.
+var errJSONDepth = errors.New("starlarkToJSON: value is too deeply nested or cyclic")
+
+// FromJSON decodes the given JSON text into a frozen Starlark value.
+//
+// Objects are decoded as dicts, arrays as lists.
+// Numbers are decoded as ints if they are integers (of any size), and as floats otherwise.
+func (a *Action) FromJSON(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var text string
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "text", &text); err != nil {
+		return nil, err
+	}
+
+	v, err := decodeJSON(strings.NewReader(text))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	return v, nil
+}
+
+// ToJSON encodes the given Starlark value as JSON text with sorted object keys.
+//
+// If indent is given, the output is indented with that number of spaces.
+// Integers are preserved exactly, and floats are always encoded with a fraction or exponent.
+func (a *Action) ToJSON(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var value starlark.Value
+	var indent int
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "value", &value, "indent??", &indent); err != nil {
+		return nil, err
+	}
+
+	if indent < 0 {
+		return nil, fmt.Errorf("%s: indent must be non-negative, got %d", fn.Name(), indent)
+	}
+
+	s, err := encodeJSON(value, strings.Repeat(" ", indent))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	return starlark.String(s), nil
+}
+
+// decodeJSON decodes a single JSON value from the given reader into a frozen Starlark value.
+func decodeJSON(r io.Reader) (starlark.Value, error) {
+	d := json.NewDecoder(r)
+	d.UseNumber()
+
+	var v any
+	if err := d.Decode(&v); err != nil {
+		return nil, fmt.Errorf("decodeJSON: %w", err)
+	}
+
+	if _, err := d.Token(); err != io.EOF {
+		return nil, fmt.Errorf("decodeJSON: unexpected data after JSON value")
+	}
+
+	res, err := jsonToStarlark(v)
+	if err != nil {
+		return nil, fmt.Errorf("decodeJSON: %w", err)
+	}
+
+	res.Freeze()
+	return res, nil
+}
+
+// starlarkToJSON converts a Starlark value to a Go value that could be encoded to JSON
+// ([]any, map[string]any, nil/null, bool, string, [json.Number]).
+// It is the inverse of [jsonToStarlark].
+//
+// Integers are preserved exactly, and floats are always encoded with a fraction or exponent,
+// so they are decoded back as floats.
+func starlarkToJSON(v starlark.Value, depth int) (any, error) {
+	if depth > maxJSONDepth {
+		return nil, errJSONDepth
+	}
+
+	switch v := v.(type) {
+	case starlark.NoneType:
+		return nil, nil
+
+	case starlark.Bool:
+		return bool(v), nil
+
+	case starlark.String:
+		return string(v), nil
+
+	case starlark.Int:
+		return json.Number(v.String()), nil
+
+	case starlark.Float:
+		f := float64(v)
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("starlarkToJSON: cannot encode non-finite float %v", v)
+		}
+
+		s := strconv.FormatFloat(f, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".e") {
+			s += ".0"
+		}
+
+		return json.Number(s), nil
+
+	case *lazyEvent:
+		ev, err := v.load()
+		if err != nil {
+			return nil, fmt.Errorf("starlarkToJSON: %w", err)
+		}
+
+		return starlarkToJSON(ev, depth)
+
+	case starlark.IterableMapping:
+		m := make(map[string]any)
+		for _, item := range v.Items() {
+			k, ok := item[0].(starlark.String)
+			if !ok {
+				return nil, fmt.Errorf("starlarkToJSON: dict key %s is %s, want string", item[0], item[0].Type())
+			}
+
+			jv, err := starlarkToJSON(item[1], depth+1)
+			if errors.Is(err, errJSONDepth) {
+				return nil, err
+			}
+
+			if err != nil {
+				return nil, fmt.Errorf("starlarkToJSON: key %q: %w", string(k), err)
+			}
+
+			m[string(k)] = jv
+		}
+
+		return m, nil
+
+	case *starlarkstruct.Struct:
+		m := make(map[string]any)
+		for _, name := range v.AttrNames() {
+			av, err := v.Attr(name)
+			if err != nil {
+				return nil, fmt.Errorf("starlarkToJSON: field %q: %w", name, err)
+			}
+
+			jv, err := starlarkToJSON(av, depth+1)
+			if errors.Is(err, errJSONDepth) {
+				return nil, err
+			}
+
+			if err != nil {
+				return nil, fmt.Errorf("starlarkToJSON: field %q: %w", name, err)
+			}
+
+			m[name] = jv
+		}
+
+		return m, nil
+
+	case starlark.Iterable:
+		elems := []any{}
+
+		iter := v.Iterate()
+		defer iter.Done()
+
+		var e starlark.Value
+		for i := 0; iter.Next(&e); i++ {
+			jv, err := starlarkToJSON(e, depth+1)
+			if errors.Is(err, errJSONDepth) {
+				return nil, err
+			}
+
+			if err != nil {
+				return nil, fmt.Errorf("starlarkToJSON: index %d: %w", i, err)
+			}
+
+			elems = append(elems, jv)
+		}
+
+		return elems, nil
+
+	default:
+		return nil, fmt.Errorf("starlarkToJSON: unsupported type %s", v.Type())
+	}
+}
+
+// encodeJSON encodes a Starlark value as canonical JSON, with dict keys sorted.
+// If indent is not empty, the output is indented.
+func encodeJSON(v starlark.Value, indent string) (string, error) {
+	jv, err := starlarkToJSON(v, 0)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	e := json.NewEncoder(&buf)
+	e.SetEscapeHTML(false)
+	e.SetIndent("", indent)
+
+	if err = e.Encode(jv); err != nil {
+		return "", fmt.Errorf("encodeJSON: %w", err)
+	}
+
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// stringOrJSON returns strings as is and encodes other values as JSON.
+func stringOrJSON(v starlark.Value) (string, error) {
+	if s, ok := v.(starlark.String); ok {
+		return string(s), nil
+	}
+
+	return encodeJSON(v, "")
+}
@@ -0,0 +1,201 @@
+package githubactions
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AlekSi/should"
+	"github.com/AlekSi/should/must"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+)
+
+// eval evaluates the given Starlark expression.
+func eval(tb testing.TB, expr string) starlark.Value {
+	tb.Helper()
+
+	th := &starlark.Thread{Name: tb.Name()}
+	env := starlark.StringDict{
+		"struct": starlark.NewBuiltin("struct", starlarkstruct.Make),
+	}
+
+	v, err := starlark.EvalOptions(&syntax.FileOptions{Set: true}, th, tb.Name(), expr, env)
+	must.BeZero(tb, err)
+
+	return v
+}
+
+func TestEncodeJSON(t *testing.T) {
+	for name, tc := range map[string]struct {
+		expr     string
+		expected string
+		err      string
+	}{
+		"None": {
+			expr:     "None",
+			expected: `null`,
+		},
+		"Bool": {
+			expr:     "True",
+			expected: `true`,
+		},
+		"String": {
+			expr:     `"<a & b>\n"`,
+			expected: `"<a & b>\n"`,
+		},
+		"BigInt": {
+			expr:     "123456789012345678901234567890",
+			expected: `123456789012345678901234567890`,
+		},
+		"FloatWhole": {
+			expr:     "2.0",
+			expected: `2.0`,
+		},
+		"Float": {
+			expr:     "0.1",
+			expected: `0.1`,
+		},
+		"FloatExp": {
+			expr:     "1e100",
+			expected: `1e+100`,
+		},
+		"Dict": {
+			expr:     `{"b": [1, 2.5, (3,), set([4])], "a": None, "c": {}}`,
+			expected: `{"a":null,"b":[1,2.5,[3],[4]],"c":{}}`,
+		},
+		"EmptyList": {
+			expr:     "[]",
+			expected: `[]`,
+		},
+		"Struct": {
+			expr:     "struct(b = 1, a = 2)",
+			expected: `{"a":2,"b":1}`,
+		},
+		"NaN": {
+			expr: `float("nan")`,
+			err:  "starlarkToJSON: cannot encode non-finite float nan",
+		},
+		"Function": {
+			expr: `{"f": len}`,
+			err:  `starlarkToJSON: key "f": starlarkToJSON: unsupported type builtin_function_or_method`,
+		},
+		"Bytes": {
+			expr: `b"abc"`,
+			err:  `starlarkToJSON: unsupported type bytes`,
+		},
+		"NonStringKey": {
+			expr: `[{1: 2}]`,
+			err:  `starlarkToJSON: index 0: starlarkToJSON: dict key 1 is int, want string`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			actual, err := encodeJSON(eval(t, tc.expr), "")
+			if tc.err != "" {
+				must.NotBeZero(t, err)
+				should.BeEqual(t, err.Error(), tc.err)
+				return
+			}
+
+			must.BeZero(t, err)
+			should.BeEqual(t, actual, tc.expected)
+		})
+	}
+}
+
+func TestEncodeJSONCycle(t *testing.T) {
+	l := starlark.NewList(nil)
+	must.BeZero(t, l.Append(l))
+
+	_, err := encodeJSON(l, "")
+	must.NotBeZero(t, err)
+	should.BeEqual(t, err.Error(), "starlarkToJSON: value is too deeply nested or cyclic")
+}
+
+func TestFromJSONToJSON(t *testing.T) {
+	b, err := os.ReadFile(filepath.Join("testdata", "event.json"))
+	must.BeZero(t, err)
+
+	text := starlark.String(b)
+
+	var buf bytes.Buffer
+	th, m, _ := setup(t, &buf, nil)
+
+	v, err := starlark.Call(th, m.Members["from_json"], starlark.Tuple{text}, nil)
+	must.BeZero(t, err)
+
+	event, ok := v.(*starlark.Dict)
+	must.NotBeZero(t, ok)
+
+	err = event.SetKey(starlark.String("action"), starlark.None)
+	should.NotBeZero(t, err)
+
+	// same values as the context's event
+	expected, err := readEvent(filepath.Join("testdata", "event.json"))
+	must.BeZero(t, err)
+
+	eq, err := starlark.Equal(v, expected)
+	must.BeZero(t, err)
+	should.BeEqual(t, eq, true)
+
+	// testdata/event.json is in canonical form
+	kwargs := []starlark.Tuple{{starlark.String("indent"), starlark.MakeInt(2)}}
+	res, err := starlark.Call(th, m.Members["to_json"], starlark.Tuple{v}, kwargs)
+	must.BeZero(t, err)
+	should.BeEqual(t, res.(starlark.String)+"\n", text)
+
+	// compact form decodes to the same value
+	res, err = starlark.Call(th, m.Members["to_json"], starlark.Tuple{v}, nil)
+	must.BeZero(t, err)
+	should.BeEqual(t, strings.Contains(string(res.(starlark.String)), "\n"), false)
+
+	v, err = starlark.Call(th, m.Members["from_json"], starlark.Tuple{res}, nil)
+	must.BeZero(t, err)
+
+	eq, err = starlark.Equal(v, expected)
+	must.BeZero(t, err)
+	should.BeEqual(t, eq, true)
+
+	should.BeEqual(t, buf.String(), "")
+}
+
+func TestFromJSON(t *testing.T) {
+	for name, tc := range map[string]struct {
+		text     string
+		expected string
+		err      string
+	}{
+		"Null":   {text: `null`, expected: `None`},
+		"Int":    {text: `123456789012345678901234567890`, expected: `123456789012345678901234567890`},
+		"Float":  {text: `2.0`, expected: `2.0`},
+		"Exp":    {text: `1e3`, expected: `1000.0`},
+		"Array":  {text: `[1, "a", true, {}]`, expected: `[1, "a", True, {}]`},
+		"Object": {text: ` {"b": 1, "a": [null]} `, expected: `{"a": [None], "b": 1}`},
+		"Trailing": {
+			text: `{} {}`,
+			err:  "from_json: decodeJSON: unexpected data after JSON value",
+		},
+		"Invalid": {
+			text: `{`,
+			err:  "from_json: decodeJSON: unexpected EOF",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			th, m, _ := setup(t, &buf, nil)
+
+			res, err := starlark.Call(th, m.Members["from_json"], starlark.Tuple{starlark.String(tc.text)}, nil)
+			if tc.err != "" {
+				must.NotBeZero(t, err)
+				should.BeEqual(t, err.Error(), tc.err)
+				return
+			}
+
+			must.BeZero(t, err)
+			should.BeEqual(t, res.String(), tc.expected)
+		})
+	}
+}
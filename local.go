@@ -0,0 +1,243 @@
+package githubactions
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LocalOptions configures a synthetic GitHub Actions runner environment.
+type LocalOptions struct {
+	// EventName is the name of the event that triggered the workflow; "workflow_dispatch" by default.
+	EventName string
+
+	// EventPath is the path of the event payload JSON file; an empty object is used by default.
+	EventPath string
+
+	// Inputs are action inputs by name.
+	Inputs map[string]string
+
+	// Workspace is the workspace directory; the current directory by default.
+	Workspace string
+
+	// Env contains additional environment variables that override all others.
+	Env map[string]string
+
+	// Isolated hides the process environment, except for a few variables
+	// required to run commands (PATH, HOME, and temporary directories).
+	// Other variables, including GITHUB_TOKEN, should be passed with Env.
+	Isolated bool
+}
+
+// LocalEnv is a synthetic GitHub Actions runner environment for running scripts locally.
+//
+// It provides temporary command files (GITHUB_OUTPUT, GITHUB_ENV, etc.), the event, and inputs.
+// Other environment variables are taken from the process environment,
+// unless [LocalOptions.Isolated] is set.
+type LocalEnv struct {
+	dir      string
+	env      map[string]string
+	isolated bool
+}
+
+// localFiles are environment variables for command files created by [NewLocalEnv].
+var localFiles = []string{
+	"GITHUB_OUTPUT",
+	"GITHUB_ENV",
+	"GITHUB_PATH",
+	"GITHUB_STATE",
+	"GITHUB_STEP_SUMMARY",
+}
+
+// localIsolatedEnv are process environment variables that are visible in isolated [LocalEnv].
+var localIsolatedEnv = []string{
+	"HOME",
+	"PATH",
+	"SYSTEMROOT",
+	"TEMP",
+	"TMP",
+	"TMPDIR",
+}
+
+// NewLocalEnv creates a synthetic GitHub Actions runner environment with the given options.
+// The caller must call [LocalEnv.Close] when done.
+func NewLocalEnv(opts *LocalOptions) (*LocalEnv, error) {
+	if opts == nil {
+		opts = new(LocalOptions)
+	}
+
+	dir, err := os.MkdirTemp("", "starlark-githubactions-")
+	if err != nil {
+		return nil, fmt.Errorf("NewLocalEnv: %w", err)
+	}
+
+	e := &LocalEnv{
+		dir:      dir,
+		isolated: opts.Isolated,
+		env: map[string]string{
+			"CI":                "true",
+			"GITHUB_ACTIONS":    "true",
+			"GITHUB_EVENT_NAME": opts.EventName,
+			"GITHUB_EVENT_PATH": opts.EventPath,
+			"GITHUB_WORKSPACE":  opts.Workspace,
+			"RUNNER_TEMP":       filepath.Join(dir, "temp"),
+		},
+	}
+
+	if e.env["GITHUB_EVENT_NAME"] == "" {
+		e.env["GITHUB_EVENT_NAME"] = "workflow_dispatch"
+	}
+
+	if e.env["GITHUB_WORKSPACE"] == "" {
+		if e.env["GITHUB_WORKSPACE"], err = os.Getwd(); err != nil {
+			e.Close()
+			return nil, fmt.Errorf("NewLocalEnv: %w", err)
+		}
+	}
+
+	if err = os.Mkdir(e.env["RUNNER_TEMP"], 0o755); err != nil {
+		e.Close()
+		return nil, fmt.Errorf("NewLocalEnv: %w", err)
+	}
+
+	if e.env["GITHUB_EVENT_PATH"] == "" {
+		e.env["GITHUB_EVENT_PATH"] = filepath.Join(dir, "event.json")
+		if err = os.WriteFile(e.env["GITHUB_EVENT_PATH"], []byte("{}\n"), 0o644); err != nil {
+			e.Close()
+			return nil, fmt.Errorf("NewLocalEnv: %w", err)
+		}
+	}
+
+	for _, k := range localFiles {
+		e.env[k] = filepath.Join(dir, strings.ToLower(k))
+		if err = os.WriteFile(e.env[k], nil, 0o644); err != nil {
+			e.Close()
+			return nil, fmt.Errorf("NewLocalEnv: %w", err)
+		}
+	}
+
+	for name, v := range opts.Inputs {
+		e.env[inputEnv(name)] = v
+	}
+
+	for k, v := range opts.Env {
+		e.env[k] = v
+	}
+
+	return e, nil
+}
+
+// processEnv returns true if the given process environment variable is visible.
+func (e *LocalEnv) processEnv(key string) bool {
+	return !e.isolated || slices.Contains(localIsolatedEnv, key)
+}
+
+// Close removes temporary files.
+func (e *LocalEnv) Close() error {
+	return os.RemoveAll(e.dir)
+}
+
+// Getenv returns the value of the environment variable.
+// It has the same signature as [os.Getenv].
+func (e *LocalEnv) Getenv(key string) string {
+	if v, ok := e.env[key]; ok {
+		return v
+	}
+
+	if !e.processEnv(key) {
+		return ""
+	}
+
+	return os.Getenv(key)
+}
+
+// Environ returns the whole environment.
+// It has the same signature as [os.Environ].
+func (e *LocalEnv) Environ() []string {
+	var res []string
+	for _, kv := range os.Environ() {
+		k, _, _ := strings.Cut(kv, "=")
+		if _, ok := e.env[k]; !ok && e.processEnv(k) {
+			res = append(res, kv)
+		}
+	}
+
+	for k, v := range e.env {
+		res = append(res, k+"="+v)
+	}
+
+	slices.Sort(res)
+	return res
+}
+
+// Report writes a human-readable report of outputs, environment variables, path entries, state,
+// and job summary produced by the script.
+func (e *LocalEnv) Report(w io.Writer) error {
+	var buf bytes.Buffer
+
+	for _, section := range []struct {
+		title string
+		env   string
+	}{
+		{"Outputs", "GITHUB_OUTPUT"},
+		{"Environment", "GITHUB_ENV"},
+		{"State", "GITHUB_STATE"},
+	} {
+		commands, err := ReadFileCommands(e.env[section.env])
+		if err != nil {
+			return fmt.Errorf("Report: %w", err)
+		}
+
+		fmt.Fprintf(&buf, "%s:\n", section.title)
+		for _, c := range commands {
+			fmt.Fprintf(&buf, "  %s = %s\n", c.Name, strings.ReplaceAll(c.Value, "\n", "\n    "))
+		}
+	}
+
+	paths, err := ReadPathFile(e.env["GITHUB_PATH"])
+	if err != nil {
+		return fmt.Errorf("Report: %w", err)
+	}
+
+	fmt.Fprintf(&buf, "Path:\n")
+	for _, p := range paths {
+		fmt.Fprintf(&buf, "  %s\n", p)
+	}
+
+	b, err := os.ReadFile(e.env["GITHUB_STEP_SUMMARY"])
+	if err != nil {
+		return fmt.Errorf("Report: %w", err)
+	}
+
+	fmt.Fprintf(&buf, "Summary:\n")
+	if s := strings.TrimSuffix(string(b), "\n"); s != "" {
+		fmt.Fprintf(&buf, "  %s\n", strings.ReplaceAll(s, "\n", "\n  "))
+	}
+
+	if _, err = buf.WriteTo(w); err != nil {
+		return fmt.Errorf("Report: %w", err)
+	}
+
+	return nil
+}
+
+// ReadInputs reads action inputs from the YAML (or JSON) file containing a mapping of names to scalar values.
+func ReadInputs(path string) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ReadInputs: %w", err)
+	}
+
+	var res map[string]string
+	if err = yaml.Unmarshal(b, &res); err != nil {
+		return nil, fmt.Errorf("ReadInputs: %s: %w", path, err)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,97 @@
+package githubactions
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/AlekSi/should"
+	"github.com/AlekSi/should/must"
+	"github.com/sethvargo/go-githubactions"
+	"go.starlark.net/starlark"
+)
+
+func TestLocalEnv(t *testing.T) {
+	inputs := filepath.Join(t.TempDir(), "inputs.json")
+	must.BeZero(t, os.WriteFile(inputs, []byte(`{"name": "World", "count": 3, "debug": true}`), 0o644))
+
+	in, err := ReadInputs(inputs)
+	must.BeZero(t, err)
+	should.BeEqual(t, in, map[string]string{"name": "World", "count": "3", "debug": "true"})
+
+	env, err := NewLocalEnv(&LocalOptions{
+		Inputs: in,
+		Env:    map[string]string{"GITHUB_REPOSITORY": "o/r"},
+	})
+	must.BeZero(t, err)
+
+	defer env.Close()
+
+	should.BeEqual(t, env.Getenv("GITHUB_EVENT_NAME"), "workflow_dispatch")
+	should.BeEqual(t, env.Getenv("GITHUB_REPOSITORY"), "o/r")
+	should.BeEqual(t, env.Getenv("INPUT_COUNT"), "3")
+
+	var buf bytes.Buffer
+	a := New(githubactions.New(githubactions.WithWriter(&buf), githubactions.WithGetenv(env.Getenv)), WithEnviron(env.Environ))
+	m := NewModule("githubactions", a)
+	th := &starlark.Thread{Name: t.Name()}
+
+	_, err = starlark.Call(th, m.Members["set_output"], starlark.Tuple{starlark.String("name"), starlark.String("World")}, nil)
+	must.BeZero(t, err)
+
+	ctx, err := starlark.Call(th, m.Members["context"], nil, nil)
+	must.BeZero(t, err)
+
+	event, err := ctx.(starlark.HasAttrs).Attr("event")
+	must.BeZero(t, err)
+	should.BeEqual(t, event.String(), "{}")
+
+	var report bytes.Buffer
+	must.BeZero(t, env.Report(&report))
+
+	expected := "Outputs:\n" +
+		"  name = World\n" +
+		"Environment:\n" +
+		"State:\n" +
+		"Path:\n" +
+		"Summary:\n"
+	should.BeEqual(t, report.String(), expected)
+
+	must.BeZero(t, env.Close())
+
+	_, err = os.Stat(env.Getenv("GITHUB_OUTPUT"))
+	should.BeEqual(t, os.IsNotExist(err), true)
+}
+
+func TestLocalEnvProcessEnv(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "ghp_process")
+	t.Setenv("GITHUB_REPOSITORY", "process/repo")
+
+	env, err := NewLocalEnv(nil)
+	must.BeZero(t, err)
+
+	defer env.Close()
+
+	should.BeEqual(t, env.Getenv("GITHUB_TOKEN"), "ghp_process")
+	should.BeEqual(t, env.Getenv("GITHUB_REPOSITORY"), "process/repo")
+	should.BeEqual(t, slices.Contains(env.Environ(), "GITHUB_REPOSITORY=process/repo"), true)
+
+	isolated, err := NewLocalEnv(&LocalOptions{
+		Env:      map[string]string{"GITHUB_REPOSITORY": "o/r"},
+		Isolated: true,
+	})
+	must.BeZero(t, err)
+
+	defer isolated.Close()
+
+	should.BeEqual(t, isolated.Getenv("GITHUB_TOKEN"), "")
+	should.BeEqual(t, isolated.Getenv("GITHUB_REPOSITORY"), "o/r")
+	should.BeEqual(t, isolated.Getenv("PATH"), os.Getenv("PATH"))
+
+	for _, kv := range isolated.Environ() {
+		should.BeEqual(t, strings.HasPrefix(kv, "GITHUB_TOKEN="), false)
+	}
+}
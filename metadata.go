@@ -0,0 +1,238 @@
+package githubactions
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"gopkg.in/yaml.v3"
+)
+
+// ActionMetadata represents the action metadata file (action.yml).
+// See https://docs.github.com/en/actions/reference/workflows-and-actions/metadata-syntax.
+type ActionMetadata struct {
+	Name        string                  `yaml:"name"`
+	Author      string                  `yaml:"author,omitempty"`
+	Description string                  `yaml:"description"`
+	Inputs      map[string]ActionInput  `yaml:"inputs,omitempty"`
+	Outputs     map[string]ActionOutput `yaml:"outputs,omitempty"`
+	Runs        map[string]any          `yaml:"runs"`
+}
+
+// ActionInput represents the declared action input.
+type ActionInput struct {
+	Description        string `yaml:"description"`
+	Required           bool   `yaml:"required,omitempty"`
+	Default            string `yaml:"default,omitempty"`
+	DeprecationMessage string `yaml:"deprecationMessage,omitempty"`
+}
+
+// UnmarshalYAML implements [yaml.Unmarshaler].
+// Like the runner, it accepts required given as a string ("true" or "false") as well as a boolean.
+func (in *ActionInput) UnmarshalYAML(value *yaml.Node) error {
+	var raw struct {
+		Description        string `yaml:"description"`
+		Required           string `yaml:"required"`
+		Default            string `yaml:"default"`
+		DeprecationMessage string `yaml:"deprecationMessage"`
+	}
+	if err := value.Decode(&raw); err != nil {
+		return err
+	}
+
+	var required bool
+	if s := strings.TrimSpace(raw.Required); s != "" {
+		var err error
+		if required, err = strconv.ParseBool(s); err != nil {
+			return fmt.Errorf("line %d: invalid required value %q, must be true or false", value.Line, raw.Required)
+		}
+	}
+
+	*in = ActionInput{
+		Description:        raw.Description,
+		Required:           required,
+		Default:            raw.Default,
+		DeprecationMessage: raw.DeprecationMessage,
+	}
+
+	return nil
+}
+
+// ActionOutput represents the declared action output.
+type ActionOutput struct {
+	Description string `yaml:"description"`
+	Value       string `yaml:"value,omitempty"`
+}
+
+// WithActionMetadata enables enforcement of the given action metadata:
+//   - getting an undeclared input is an error;
+//   - declared defaults are used for empty inputs;
+//   - setting an undeclared output is an error.
+//
+// Supplied deprecated inputs are not reported, as the runner already warns about them;
+// see [WithDeprecationWarnings] for running outside the runner.
+//
+// See [LoadActionMetadata].
+func WithActionMetadata(md *ActionMetadata) Option {
+	return func(a *Action) {
+		a.metadata = md
+	}
+}
+
+// WithDeprecationWarnings makes input builtins warn once about supplied deprecated inputs
+// of the enforced action metadata (see [WithActionMetadata]), like the runner does.
+// It is intended for running outside the runner, for example, with [LocalEnv].
+func WithDeprecationWarnings() Option {
+	return func(a *Action) {
+		a.deprecationWarnings = true
+	}
+}
+
+// LoadActionMetadata reads action.yml (or action.yaml) from the given action directory,
+// typically GITHUB_ACTION_PATH (action_path of the context).
+//
+// It returns an error wrapping [fs.ErrNotExist] if there is no metadata file.
+func LoadActionMetadata(dir string) (*ActionMetadata, error) {
+	var b []byte
+	var path string
+	var err error
+	for _, name := range []string{"action.yml", "action.yaml"} {
+		path = filepath.Join(dir, name)
+		if b, err = os.ReadFile(path); !errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("LoadActionMetadata: %w", err)
+	}
+
+	var res ActionMetadata
+	if err = yaml.Unmarshal(b, &res); err != nil {
+		return nil, fmt.Errorf("LoadActionMetadata: %s: %w", path, err)
+	}
+
+	return &res, nil
+}
+
+// actionMetadata returns the action metadata, or nil if enforcement is not enabled.
+func (a *Action) actionMetadata() *ActionMetadata {
+	a.metadataM.Lock()
+	defer a.metadataM.Unlock()
+
+	return a.metadata
+}
+
+// input returns the declared name and the input with the given name (compared like the runner does),
+// and false if it is not declared.
+func (md *ActionMetadata) input(name string) (string, ActionInput, bool) {
+	env := inputEnv(name)
+	for n, in := range md.Inputs {
+		if inputEnv(n) == env {
+			return n, in, true
+		}
+	}
+
+	return "", ActionInput{}, false
+}
+
+// metadataInput checks that the input is declared, warns if it is deprecated and supplied
+// (only with [WithDeprecationWarnings]), and applies the declared default.
+// It returns the given value as is if metadata enforcement is not enabled.
+func (a *Action) metadataInput(fn *starlark.Builtin, name, value string) (string, error) {
+	md := a.actionMetadata()
+	if md == nil {
+		return value, nil
+	}
+
+	declared, in, ok := md.input(name)
+	if !ok {
+		return "", fmt.Errorf("%s: input %q is not declared in action metadata", fn.Name(), name)
+	}
+
+	if a.deprecationWarnings && in.DeprecationMessage != "" && value != "" {
+		a.metadataM.Lock()
+		if a.deprecationWarned == nil {
+			a.deprecationWarned = make(map[string]struct{})
+		}
+		_, warned := a.deprecationWarned[declared]
+		a.deprecationWarned[declared] = struct{}{}
+		a.metadataM.Unlock()
+
+		if !warned {
+			a.a.Warningf("Input '%s' has been deprecated with message: %s", declared, in.DeprecationMessage)
+		}
+	}
+
+	if value == "" {
+		value = in.Default
+	}
+
+	return value, nil
+}
+
+// metadataOutput checks that the output is declared, if metadata enforcement is enabled.
+func (a *Action) metadataOutput(fn *starlark.Builtin, name string) error {
+	md := a.actionMetadata()
+	if md == nil {
+		return nil
+	}
+
+	if _, ok := md.Outputs[name]; !ok {
+		return fmt.Errorf("%s: output %q is not declared in action metadata", fn.Name(), name)
+	}
+
+	return nil
+}
+
+// ActionMetadata returns action metadata as a frozen struct with name, description, inputs, and outputs fields,
+// or None if metadata enforcement is not enabled.
+//
+// Inputs is a dict of structs with description, required, default, and deprecation_message fields;
+// outputs is a dict of structs with description field.
+func (a *Action) ActionMetadata(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs); err != nil {
+		return nil, err
+	}
+
+	md := a.actionMetadata()
+	if md == nil {
+		return starlark.None, nil
+	}
+
+	inputs := starlark.NewDict(len(md.Inputs))
+	for _, name := range slices.Sorted(maps.Keys(md.Inputs)) {
+		in := md.Inputs[name]
+		_ = inputs.SetKey(starlark.String(name), starlarkstruct.FromStringDict(starlark.String("input"), starlark.StringDict{
+			"description":         starlark.String(in.Description),
+			"required":            starlark.Bool(in.Required),
+			"default":             starlark.String(in.Default),
+			"deprecation_message": starlark.String(in.DeprecationMessage),
+		}))
+	}
+
+	outputs := starlark.NewDict(len(md.Outputs))
+	for _, name := range slices.Sorted(maps.Keys(md.Outputs)) {
+		_ = outputs.SetKey(starlark.String(name), starlarkstruct.FromStringDict(starlark.String("output"), starlark.StringDict{
+			"description": starlark.String(md.Outputs[name].Description),
+		}))
+	}
+
+	res := starlarkstruct.FromStringDict(starlark.String("action_metadata"), starlark.StringDict{
+		"name":        starlark.String(md.Name),
+		"description": starlark.String(md.Description),
+		"inputs":      inputs,
+		"outputs":     outputs,
+	})
+
+	res.Freeze()
+	return res, nil
+}
@@ -0,0 +1,133 @@
+package githubactions
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"testing"
+
+	"github.com/AlekSi/should"
+	"github.com/AlekSi/should/must"
+	"go.starlark.net/starlark"
+	"gopkg.in/yaml.v3"
+)
+
+func TestLoadActionMetadata(t *testing.T) {
+	md, err := LoadActionMetadata("testdata/action")
+	must.BeZero(t, err)
+
+	should.BeEqual(t, md.Name, "Greet")
+	should.BeEqual(t, md.Inputs["who"], ActionInput{Description: "Who to greet.", Required: true, Default: "World"})
+	should.BeEqual(t, md.Inputs["greeting"], ActionInput{Description: "Greeting to use."})
+	should.BeEqual(t, md.Inputs["name"].DeprecationMessage, "Use who instead.")
+	should.BeEqual(t, md.Outputs, map[string]ActionOutput{"message": {Description: "Greeting message."}})
+	should.BeEqual(t, md.Runs["using"], "composite")
+
+	_, err = LoadActionMetadata("testdata")
+	must.NotBeZero(t, err)
+	should.BeEqual(t, errors.Is(err, fs.ErrNotExist), true)
+}
+
+func TestActionMetadata(t *testing.T) {
+	md, err := LoadActionMetadata("testdata/action")
+	must.BeZero(t, err)
+
+	env := map[string]string{
+		"INPUT_NAME": "Alice",
+	}
+
+	var buf bytes.Buffer
+	th, m, getenv := setupEnv(t, &buf, func(key string) string { return env[key] }, WithActionMetadata(md))
+
+	res, err := starlark.Call(th, m.Members["get_input"], starlark.Tuple{starlark.String("who")}, nil)
+	must.BeZero(t, err)
+	should.BeEqual(t, res, starlark.String("World"))
+
+	res, err = starlark.Call(th, m.Members["get_input"], starlark.Tuple{starlark.String("greeting")}, nil)
+	must.BeZero(t, err)
+	should.BeEqual(t, res, starlark.String(""))
+
+	for range 2 {
+		res, err = starlark.Call(th, m.Members["get_input"], starlark.Tuple{starlark.String("name")}, nil)
+		must.BeZero(t, err)
+		should.BeEqual(t, res, starlark.String("Alice"))
+	}
+
+	_, err = starlark.Call(th, m.Members["get_input"], starlark.Tuple{starlark.String("whom")}, nil)
+	must.NotBeZero(t, err)
+	should.BeEqual(t, err.Error(), `get_input: input "whom" is not declared in action metadata`)
+
+	_, err = starlark.Call(th, m.Members["get_bool_input"], starlark.Tuple{starlark.String("debug")}, nil)
+	must.NotBeZero(t, err)
+	should.BeEqual(t, err.Error(), `get_bool_input: input "debug" is not declared in action metadata`)
+
+	_, err = starlark.Call(th, m.Members["set_output"], starlark.Tuple{starlark.String("message"), starlark.String("Hello")}, nil)
+	must.BeZero(t, err)
+
+	_, err = starlark.Call(th, m.Members["set_output"], starlark.Tuple{starlark.String("messages"), starlark.String("Hello")}, nil)
+	must.NotBeZero(t, err)
+	should.BeEqual(t, err.Error(), `set_output: output "messages" is not declared in action metadata`)
+
+	commands, err := ReadFileCommands(getenv("GITHUB_OUTPUT"))
+	must.BeZero(t, err)
+	should.BeEqual(t, commands, []FileCommand{{Name: "message", Value: "Hello"}})
+
+	res, err = starlark.Call(th, m.Members["action_metadata"], nil, nil)
+	must.BeZero(t, err)
+
+	expected := `action_metadata(description = "Greets someone.", inputs = {` +
+		`"greeting": input(default = "", deprecation_message = "", description = "Greeting to use.", required = False), ` +
+		`"name": input(default = "", deprecation_message = "Use who instead.", description = "Old name of the who input.", required = False), ` +
+		`"who": input(default = "World", deprecation_message = "", description = "Who to greet.", required = True)}, ` +
+		`name = "Greet", outputs = {"message": output(description = "Greeting message.")})`
+	should.BeEqual(t, res.String(), expected)
+
+	// the runner warns about deprecated inputs itself
+	should.BeEqual(t, buf.String(), "")
+
+	buf.Reset()
+	th, m, _ = setupEnv(t, &buf, func(key string) string { return env[key] }, WithActionMetadata(md), WithDeprecationWarnings())
+
+	for range 2 {
+		res, err = starlark.Call(th, m.Members["get_input"], starlark.Tuple{starlark.String("NAME")}, nil)
+		must.BeZero(t, err)
+		should.BeEqual(t, res, starlark.String("Alice"))
+	}
+
+	// not supplied
+	_, err = starlark.Call(th, m.Members["get_input"], starlark.Tuple{starlark.String("greeting")}, nil)
+	must.BeZero(t, err)
+
+	should.BeEqual(t, buf.String(), "::warning::Input 'name' has been deprecated with message: Use who instead.\n")
+}
+
+func TestActionInputRequired(t *testing.T) {
+	for name, tc := range map[string]struct {
+		input    string
+		expected bool
+		err      string
+	}{
+		"Missing":     {input: "description: x"},
+		"Bool":        {input: "required: true", expected: true},
+		"String":      {input: "required: 'true'", expected: true},
+		"StringFalse": {input: "required: \"false\""},
+		"Empty":       {input: "required: ''"},
+		"Invalid": {
+			input: "required: sometimes",
+			err:   `line 1: invalid required value "sometimes", must be true or false`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var in ActionInput
+			err := yaml.Unmarshal([]byte(tc.input), &in)
+			if tc.err != "" {
+				must.NotBeZero(t, err)
+				should.BeEqual(t, err.Error(), tc.err)
+				return
+			}
+
+			must.BeZero(t, err)
+			should.BeEqual(t, in.Required, tc.expected)
+		})
+	}
+}
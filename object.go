@@ -0,0 +1,244 @@
+package githubactions
+
+import (
+	"fmt"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+)
+
+// objectMethods are dict methods supported by [Object].
+var objectMethods = []string{"get", "items", "keys", "values"}
+
+// Object is a frozen JSON object (such as the event payload)
+// that supports both attribute access (obj.pull_request.head.sha)
+// and dict operations (obj["pull_request"], obj.get("pull_request"), "pull_request" in obj).
+//
+// Keys that are not valid identifiers or that clash with dict methods (get, items, keys, values)
+// are available only via indexing.
+// Accessing an attribute for a missing key is an error;
+// use the dig builtin for safe navigation.
+//
+// Objects are hashable if all their values are hashable.
+type Object struct {
+	d *starlark.Dict
+}
+
+// check interfaces
+var (
+	_ starlark.IterableMapping = (*Object)(nil)
+	_ starlark.Sequence        = (*Object)(nil)
+	_ starlark.HasAttrs        = (*Object)(nil)
+	_ starlark.Comparable      = (*Object)(nil)
+)
+
+// toObject converts dicts in the given value to [*Object] values and lists to tuples, recursively.
+// The result is frozen.
+func toObject(v starlark.Value) (starlark.Value, error) {
+	switch v := v.(type) {
+	case *starlark.Dict:
+		d := starlark.NewDict(v.Len())
+		for _, item := range v.Items() {
+			ov, err := toObject(item[1])
+			if err != nil {
+				return nil, fmt.Errorf("toObject: key %s: %w", item[0], err)
+			}
+
+			if err = d.SetKey(item[0], ov); err != nil {
+				return nil, fmt.Errorf("toObject: key %s: %w", item[0], err)
+			}
+		}
+
+		d.Freeze()
+		return &Object{d: d}, nil
+
+	case *starlark.List:
+		t := make(starlark.Tuple, v.Len())
+		for i := range t {
+			ov, err := toObject(v.Index(i))
+			if err != nil {
+				return nil, fmt.Errorf("toObject: index %d: %w", i, err)
+			}
+
+			t[i] = ov
+		}
+
+		return t, nil
+
+	default:
+		v.Freeze()
+		return v, nil
+	}
+}
+
+// String implements [starlark.Value].
+func (o *Object) String() string {
+	return o.d.String()
+}
+
+// Type implements [starlark.Value].
+func (o *Object) Type() string {
+	return "object"
+}
+
+// Freeze implements [starlark.Value].
+func (o *Object) Freeze() {
+	// always frozen
+}
+
+// Truth implements [starlark.Value].
+func (o *Object) Truth() starlark.Bool {
+	return o.d.Truth()
+}
+
+// Hash implements [starlark.Value].
+//
+// It does not depend on the order of keys.
+func (o *Object) Hash() (uint32, error) {
+	var res uint32
+	for _, item := range o.d.Items() {
+		kh, err := item[0].Hash()
+		if err != nil {
+			return 0, err
+		}
+
+		vh, err := item[1].Hash()
+		if err != nil {
+			return 0, err
+		}
+
+		res ^= kh*31 + vh
+	}
+
+	return res, nil
+}
+
+// Get implements [starlark.Mapping].
+func (o *Object) Get(k starlark.Value) (starlark.Value, bool, error) {
+	return o.d.Get(k)
+}
+
+// Iterate implements [starlark.Iterable].
+func (o *Object) Iterate() starlark.Iterator {
+	return o.d.Iterate()
+}
+
+// Items implements [starlark.IterableMapping].
+func (o *Object) Items() []starlark.Tuple {
+	return o.d.Items()
+}
+
+// Len implements [starlark.Sequence].
+func (o *Object) Len() int {
+	return o.d.Len()
+}
+
+// Attr implements [starlark.HasAttrs].
+func (o *Object) Attr(name string) (starlark.Value, error) {
+	for _, m := range objectMethods {
+		if name == m {
+			return o.d.Attr(name)
+		}
+	}
+
+	v, found, err := o.d.Get(starlark.String(name))
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// AttrNames implements [starlark.HasAttrs].
+func (o *Object) AttrNames() []string {
+	res := append([]string(nil), objectMethods...)
+	for _, k := range o.d.Keys() {
+		res = append(res, string(k.(starlark.String)))
+	}
+
+	return res
+}
+
+// CompareSameType implements [starlark.Comparable].
+func (o *Object) CompareSameType(op syntax.Token, y starlark.Value, depth int) (bool, error) {
+	switch op {
+	case syntax.EQL, syntax.NEQ:
+		eq, err := starlark.EqualDepth(o.d, y.(*Object).d, depth)
+		if err != nil {
+			return false, err
+		}
+
+		return eq == (op == syntax.EQL), nil
+
+	default:
+		return false, fmt.Errorf("%s %s %s not implemented", o.Type(), op, y.Type())
+	}
+}
+
+// Dig returns the value at the given path of keys, indexes, and attributes,
+// or default (None if not given) if any element of the path is missing or None.
+//
+// For example, dig(ctx.event, "pull_request", "labels", 0, "name") returns
+// the name of the first label of the pull request, or None for other events.
+func (a *Action) Dig(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	def := starlark.Value(starlark.None)
+	if err := starlark.UnpackArgs(fn.Name(), nil, kwargs, "default?", &def); err != nil {
+		return nil, err
+	}
+
+	if len(args) == 0 {
+		return nil, fmt.Errorf("%s: missing argument for value", fn.Name())
+	}
+
+	v := args[0]
+	for _, p := range args[1:] {
+		if v == starlark.None {
+			return def, nil
+		}
+
+		var next starlark.Value
+		switch p := p.(type) {
+		case starlark.String:
+			if m, ok := v.(starlark.Mapping); ok {
+				if mv, found, err := m.Get(p); err == nil && found {
+					next = mv
+				}
+			} else if h, ok := v.(starlark.HasAttrs); ok {
+				if av, err := h.Attr(string(p)); err == nil {
+					next = av
+				}
+			}
+
+		case starlark.Int:
+			i, ok := p.Int64()
+			if !ok {
+				break
+			}
+
+			if idx, ok := v.(starlark.Indexable); ok {
+				if i < 0 {
+					i += int64(idx.Len())
+				}
+
+				if i >= 0 && i < int64(idx.Len()) {
+					next = idx.Index(int(i))
+				}
+			}
+
+		default:
+			return nil, fmt.Errorf("%s: path element %s is %s, want string or int", fn.Name(), p, p.Type())
+		}
+
+		if next == nil {
+			return def, nil
+		}
+
+		v = next
+	}
+
+	if v == starlark.None {
+		return def, nil
+	}
+
+	return v, nil
+}
@@ -0,0 +1,81 @@
+package githubactions
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/AlekSi/should"
+	"github.com/AlekSi/should/must"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+)
+
+func TestObject(t *testing.T) {
+	var buf bytes.Buffer
+	th, m, _ := setupEnv(t, &buf, func(key string) string {
+		if key == "GITHUB_EVENT_PATH" {
+			return "testdata/event.json"
+		}
+		return ""
+	})
+
+	kwargs := []starlark.Tuple{{starlark.String("typed_event"), starlark.True}}
+	ctx, err := starlark.Call(th, m.Members["context"], nil, kwargs)
+	must.BeZero(t, err)
+
+	other, err := toObject(eval(t, `{"labels": [{"name": "bug"}, {"name": "docs"}], "draft": None, "keys": 1}`))
+	must.BeZero(t, err)
+
+	env := starlark.StringDict{
+		"ctx":   ctx,
+		"other": other,
+		"dig":   m.Members["dig"],
+	}
+
+	for expr, expected := range map[string]string{
+		`ctx.event.pull_request.head.sha`:                         `"389be79e3b2f40498966166c1e1e0e6791ea49b6"`,
+		`ctx.event["pull_request"]["head"]["sha"]`:                `"389be79e3b2f40498966166c1e1e0e6791ea49b6"`,
+		`ctx.event.get("pull_request", {}).get("auto_merge", {})`: `{"commit_message": "", "commit_title": "Export more identifiers (#2)", ` + `"enabled_by": {"avatar_url": "https://avatars.githubusercontent.com/u/11512?v=4", "events_url": "https://api.github.com/users/AlekSi/events{/privacy}", "followers_url": "https://api.github.com/users/AlekSi/followers", "following_url": "https://api.github.com/users/AlekSi/following{/other_user}", "gists_url": "https://api.github.com/users/AlekSi/gists{/gist_id}", "gravatar_id": "", "html_url": "https://github.com/AlekSi", "id": 11512, "login": "AlekSi", "node_id": "MDQ6VXNlcjExNTEy", "organizations_url": "https://api.github.com/users/AlekSi/orgs", "received_events_url": "https://api.github.com/users/AlekSi/received_events", "repos_url": "https://api.github.com/users/AlekSi/repos", "site_admin": False, "starred_url": "https://api.github.com/users/AlekSi/starred{/owner}{/repo}", "subscriptions_url": "https://api.github.com/users/AlekSi/subscriptions", "type": "User", "url": "https://api.github.com/users/AlekSi", "user_view_type": "public"}, "merge_method": "squash"}`,
+		`ctx.event.pull_request.auto_merge.merge_method`:          `"squash"`,
+		`ctx.event.pull_request.labels`:                           `()`,
+		`"pull_request" in ctx.event`:                             `True`,
+		`hasattr(ctx.event, "issue")`:                             `False`,
+		`type(ctx.event)`:                                         `"event"`,
+		`type(dig(ctx, "event", "pull_request"))`:                 `"object"`,
+		`len(other)`:                                    `3`,
+		`sorted(other.keys())`:                          `["draft", "keys", "labels"]`,
+		`other["keys"]`:                                 `1`,
+		`[l.name for l in other.labels]`:                `["bug", "docs"]`,
+		`{ctx.event: 1}[ctx.event]`:                     `1`,
+		`{other: 1}[other]`:                             `1`,
+		`other == other`:                                `True`,
+		`other == ctx.event`:                            `False`,
+		`dig(ctx.event, "pull_request", "head", "sha")`: `"389be79e3b2f40498966166c1e1e0e6791ea49b6"`,
+		`dig(ctx.event, "issue", "number")`:             `None`,
+		`dig(ctx.event, "issue", "number", default=0)`:  `0`,
+		`dig(ctx, "event", "number")`:                   `1`,
+		`dig(other, "labels", -1, "name")`:              `"docs"`,
+		`dig(other, "labels", 2, "name")`:               `None`,
+		`dig(other, "draft", default=False)`:            `False`,
+		`dig({"a": [1]}, "a", 0)`:                       `1`,
+	} {
+		t.Run(expr, func(t *testing.T) {
+			v, err := starlark.EvalOptions(&syntax.FileOptions{}, th, t.Name(), expr, env)
+			must.BeZero(t, err)
+			should.BeEqual(t, v.String(), expected)
+		})
+	}
+
+	for expr, expected := range map[string]string{
+		`ctx.event.issue`:                         `event has no .issue field or method`,
+		`ctx.event["number"] = 2`:                 `event value does not support item assignment`,
+		`dig(ctx.event, 1.5)`:                     `dig: path element 1.5 is float, want string or int`,
+		`ctx.event.pull_request.labels.append(1)`: `tuple has no .append field or method`,
+	} {
+		t.Run(expr, func(t *testing.T) {
+			_, err := starlark.ExecFileOptions(&syntax.FileOptions{}, th, t.Name(), expr, env)
+			must.NotBeZero(t, err)
+			should.BeEqual(t, err.Error(), expected)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package githubactions
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+// GetIDToken returns the GitHub OIDC token for the given audience.
+// The token is masked before it is returned.
+// See https://docs.github.com/en/actions/security-for-github-actions/security-hardening-your-deployments/about-security-hardening-with-openid-connect.
+//
+// The result is a struct with the raw token and its decoded claims.
+// Claims are not verified; they are provided for convenience only.
+func (a *Action) GetIDToken(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var audience string
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "audience??", &audience); err != nil {
+		return nil, err
+	}
+
+	if err := a.checkSideEffects(); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	ctx, done := a.cancelableContext(th)
+	defer done()
+
+	token, err := a.a.GetIDToken(ctx, audience)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	a.addMask(token, false)
+
+	claims, err := decodeClaims(token)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	res := starlarkstruct.FromStringDict(starlark.String("id_token"), starlark.StringDict{
+		"token":  starlark.String(token),
+		"claims": claims,
+	})
+
+	res.Freeze()
+	return res, nil
+}
+
+// decodeClaims decodes the payload of the given JWT without verifying its signature.
+func decodeClaims(token string) (*starlarkstruct.Struct, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("decodeClaims: expected 3 JWT parts, got %d", len(parts))
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("decodeClaims: %w", err)
+	}
+
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+
+	var c map[string]any
+	if err = d.Decode(&c); err != nil {
+		return nil, fmt.Errorf("decodeClaims: failed to decode payload: %w", err)
+	}
+
+	claims := make(starlark.StringDict, len(c))
+	for _, k := range slices.Sorted(maps.Keys(c)) {
+		v, err := jsonToStarlark(c[k])
+		if err != nil {
+			return nil, fmt.Errorf("decodeClaims: %w", err)
+		}
+
+		claims[k] = v
+	}
+
+	return starlarkstruct.FromStringDict(starlark.String("claims"), claims), nil
+}
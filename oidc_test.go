@@ -0,0 +1,169 @@
+package githubactions
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AlekSi/should"
+	"github.com/AlekSi/should/must"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+// idTokenRequestToken is a request token expected by the server returned by [newIDTokenServer].
+const idTokenRequestToken = "request-token"
+
+// newIDTokenServer starts a stand-in for the ACTIONS_ID_TOKEN_REQUEST_URL endpoint
+// and returns its URL.
+//
+// It mints unsigned JWTs with the given claims and the requested audience.
+func newIDTokenServer(tb testing.TB, claims map[string]any) string {
+	tb.Helper()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+idTokenRequestToken {
+			http.Error(w, "bad request token", http.StatusUnauthorized)
+			return
+		}
+
+		c := map[string]any{"aud": "https://github.com/owner"}
+		for k, v := range claims {
+			c[k] = v
+		}
+		if aud := r.URL.Query().Get("audience"); aud != "" {
+			c["aud"] = aud
+		}
+
+		payload, err := json.Marshal(c)
+		if err != nil {
+			tb.Error(err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		token := strings.Join([]string{
+			base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`)),
+			base64.RawURLEncoding.EncodeToString(payload),
+			"signature",
+		}, ".")
+
+		w.Header().Set("Content-Type", "application/json")
+		if err = json.NewEncoder(w).Encode(map[string]string{"value": token}); err != nil {
+			tb.Error(err)
+		}
+	}))
+	tb.Cleanup(s.Close)
+
+	return s.URL
+}
+
+func TestGetIDToken(t *testing.T) {
+	url := newIDTokenServer(t, map[string]any{
+		"repository": "owner/repo",
+		"run_id":     123456,
+	})
+
+	getenv := func(key string) string {
+		return map[string]string{
+			"ACTIONS_ID_TOKEN_REQUEST_URL":   url,
+			"ACTIONS_ID_TOKEN_REQUEST_TOKEN": idTokenRequestToken,
+		}[key]
+	}
+
+	var buf bytes.Buffer
+	th, m, _ := setupEnv(t, &buf, getenv)
+
+	kwargs := []starlark.Tuple{{starlark.String("audience"), starlark.String("sts.amazonaws.com")}}
+	res, err := starlark.Call(th, m.Members["get_id_token"], nil, kwargs)
+	must.BeZero(t, err)
+
+	s, ok := res.(*starlarkstruct.Struct)
+	must.NotBeZero(t, ok)
+
+	token, err := s.Attr("token")
+	must.BeZero(t, err)
+	should.BeEqual(t, buf.String(), "::add-mask::"+string(token.(starlark.String))+"\n")
+
+	v, err := s.Attr("claims")
+	must.BeZero(t, err)
+	claims := v.(*starlarkstruct.Struct)
+
+	v, err = claims.Attr("aud")
+	must.BeZero(t, err)
+	should.BeEqual(t, v, starlark.String("sts.amazonaws.com"))
+
+	v, err = claims.Attr("repository")
+	must.BeZero(t, err)
+	should.BeEqual(t, v, starlark.String("owner/repo"))
+
+	v, err = claims.Attr("run_id")
+	must.BeZero(t, err)
+	should.BeEqual(t, v, starlark.MakeInt(123456))
+}
+
+func TestGetIDTokenErrors(t *testing.T) {
+	url := newIDTokenServer(t, nil)
+
+	for name, tc := range map[string]struct {
+		env map[string]string
+		err string
+	}{
+		"NoURL": {
+			env: map[string]string{"ACTIONS_ID_TOKEN_REQUEST_TOKEN": idTokenRequestToken},
+			err: "get_id_token: missing ACTIONS_ID_TOKEN_REQUEST_URL in environment",
+		},
+		"NoToken": {
+			env: map[string]string{"ACTIONS_ID_TOKEN_REQUEST_URL": url},
+			err: "get_id_token: missing ACTIONS_ID_TOKEN_REQUEST_TOKEN in environment",
+		},
+		"Unauthorized": {
+			env: map[string]string{
+				"ACTIONS_ID_TOKEN_REQUEST_URL":   url,
+				"ACTIONS_ID_TOKEN_REQUEST_TOKEN": "bad",
+			},
+			err: "get_id_token: non-successful response from minting OIDC token (status 401): bad request token",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			th, m, _ := setupEnv(t, &buf, func(key string) string { return tc.env[key] })
+
+			_, err := starlark.Call(th, m.Members["get_id_token"], nil, nil)
+			must.NotBeZero(t, err)
+			should.BeEqual(t, err.Error(), tc.err)
+			should.BeEqual(t, buf.String(), "")
+		})
+	}
+}
+
+func TestGetIDTokenCancel(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	t.Cleanup(s.Close)
+
+	getenv := func(key string) string {
+		return map[string]string{
+			"ACTIONS_ID_TOKEN_REQUEST_URL":   s.URL,
+			"ACTIONS_ID_TOKEN_REQUEST_TOKEN": idTokenRequestToken,
+		}[key]
+	}
+
+	var a *Action
+	var buf bytes.Buffer
+	th, m, _ := setupEnv(t, &buf, getenv, func(action *Action) { a = action })
+
+	time.AfterFunc(100*time.Millisecond, func() { a.Cancel(th, "stop") })
+
+	start := time.Now()
+	_, err := starlark.Call(th, m.Members["get_id_token"], nil, nil)
+	must.NotBeZero(t, err)
+	should.BeEqual(t, strings.HasSuffix(err.Error(), ": stop"), true)
+	should.BeEqual(t, time.Since(start) < 5*time.Second, true)
+}
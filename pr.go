@@ -0,0 +1,424 @@
+package githubactions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+// newPRModule constructs the pr Starlark module for the given [Action].
+//
+// Builtins operate on the current pull request: the repository is taken from GITHUB_REPOSITORY,
+// and the number from the event payload (pull_request.number or issue.number).
+// All builtins accept an optional number argument to override that.
+func newPRModule(a *Action) *starlarkstruct.Module {
+	m := &starlarkstruct.Module{
+		Name:    "pr",
+		Members: make(starlark.StringDict),
+	}
+
+	for _, b := range []*starlark.Builtin{
+		starlark.NewBuiltin("comment", a.PRComment),
+		starlark.NewBuiltin("upsert_comment", a.PRUpsertComment),
+		starlark.NewBuiltin("add_labels", a.PRAddLabels),
+		starlark.NewBuiltin("remove_labels", a.PRRemoveLabels),
+		starlark.NewBuiltin("request_reviewers", a.PRRequestReviewers),
+		starlark.NewBuiltin("list_files", a.PRListFiles),
+	} {
+		m.Members[b.Name()] = b
+	}
+
+	return m
+}
+
+// prNumber returns the pull request number from the event payload.
+func (a *Action) prNumber() (int, error) {
+	event, err := a.loadEvent(a.a.Getenv("GITHUB_EVENT_PATH"), false)
+	if err != nil {
+		return 0, fmt.Errorf("prNumber: %w", err)
+	}
+
+	if d, ok := event.(*starlark.Dict); ok {
+		for _, key := range []string{"pull_request", "issue"} {
+			v, _, _ := d.Get(starlark.String(key))
+			obj, ok := v.(*starlark.Dict)
+			if !ok {
+				continue
+			}
+
+			v, _, _ = obj.Get(starlark.String("number"))
+			if n, ok := v.(starlark.Int); ok {
+				if i, ok := n.Int64(); ok && i > 0 {
+					return int(i), nil
+				}
+			}
+		}
+	}
+
+	return 0, fmt.Errorf("prNumber: no pull request number in the %q event payload", a.a.Getenv("GITHUB_EVENT_NAME"))
+}
+
+// repoPath returns the API path prefix like "repos/owner/repo" for the current repository.
+func (a *Action) repoPath() (string, error) {
+	repo := a.a.Getenv("GITHUB_REPOSITORY")
+	if owner, name, ok := strings.Cut(repo, "/"); !ok || owner == "" || name == "" {
+		return "", fmt.Errorf("repoPath: invalid GITHUB_REPOSITORY %q", repo)
+	}
+
+	return "repos/" + repo, nil
+}
+
+// prPath returns the API path prefix like "repos/owner/repo" and the pull request number.
+// If number is 0, it is taken from the event payload.
+func (a *Action) prPath(number int) (string, int, error) {
+	repo, err := a.repoPath()
+	if err != nil {
+		return "", 0, fmt.Errorf("prPath: %w", err)
+	}
+
+	if number < 0 {
+		return "", 0, fmt.Errorf("prPath: invalid number %d", number)
+	}
+
+	if number == 0 {
+		if number, err = a.prNumber(); err != nil {
+			return "", 0, fmt.Errorf("prPath: %w", err)
+		}
+	}
+
+	return repo, number, nil
+}
+
+// stringList returns a frozen Starlark list of the given iterable's elements, checking that they are strings.
+func stringList(v starlark.Iterable) (*starlark.List, error) {
+	var elems []starlark.Value
+	for e := range starlark.Elements(v) {
+		if _, ok := e.(starlark.String); !ok {
+			return nil, fmt.Errorf("stringList: got %s, want string", e.Type())
+		}
+
+		elems = append(elems, e)
+	}
+
+	res := starlark.NewList(elems)
+	res.Freeze()
+	return res, nil
+}
+
+// newDict returns a new Starlark dict with the given members.
+func newDict(members starlark.StringDict) *starlark.Dict {
+	res := starlark.NewDict(len(members))
+	for k, v := range members {
+		_ = res.SetKey(starlark.String(k), v)
+	}
+
+	return res
+}
+
+// PRComment adds a comment to the pull request and returns it.
+// Masked values are replaced in the body.
+func (a *Action) PRComment(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var body string
+	var number int
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "body", &body, "number??", &number); err != nil {
+		return nil, err
+	}
+
+	repo, number, err := a.prPath(number)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	ctx, done := a.cancelableContext(th)
+	defer done()
+
+	p := fmt.Sprintf("%s/issues/%d/comments", repo, number)
+	resp, err := a.apiRequest(ctx, http.MethodPost, p, newDict(starlark.StringDict{"body": starlark.String(a.mask(body))}), nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	return resp.value, nil
+}
+
+// githubActionsBot is the login of the GITHUB_TOKEN bot.
+const githubActionsBot = "github-actions[bot]"
+
+// prViewerLogin returns the login of the user authenticated by the API token.
+//
+// GitHub App installation tokens (including GITHUB_TOKEN) can't access the authenticated user endpoint;
+// in that case, the GITHUB_TOKEN bot login is returned.
+func (a *Action) prViewerLogin(ctx context.Context) (string, error) {
+	resp, err := a.apiRequest(ctx, http.MethodGet, "user", nil, nil)
+
+	var e *apiError
+	if errors.As(err, &e) && e.status == http.StatusForbidden {
+		return githubActionsBot, nil
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("prViewerLogin: %w", err)
+	}
+
+	if d, ok := resp.value.(*starlark.Dict); ok {
+		v, _, _ := d.Get(starlark.String("login"))
+		if login, ok := starlark.AsString(v); ok && login != "" {
+			return login, nil
+		}
+	}
+
+	return "", fmt.Errorf("prViewerLogin: no login in response")
+}
+
+// prCommentOwned returns true if the given comment was created by the user with the given login.
+func prCommentOwned(comment *starlark.Dict, login string) bool {
+	v, _, _ := comment.Get(starlark.String("user"))
+	user, ok := v.(*starlark.Dict)
+	if !ok {
+		return false
+	}
+
+	v, _, _ = user.Get(starlark.String("login"))
+	s, ok := starlark.AsString(v)
+	return ok && s == login
+}
+
+// prFindComment returns the ID of the first pull request comment containing the given text, or 0.
+//
+// Only comments created by the given author (by default, the authenticated user or bot) are considered,
+// so that a comment with the same text planted by someone else is never updated.
+func (a *Action) prFindComment(ctx context.Context, repo string, number int, text, author string) (int64, error) {
+	if author == "" {
+		var err error
+		if author, err = a.prViewerLogin(ctx); err != nil {
+			return 0, fmt.Errorf("prFindComment: %w", err)
+		}
+	}
+
+	comments, err := a.apiPaginate(ctx, fmt.Sprintf("%s/issues/%d/comments?per_page=100", repo, number), nil)
+	if err != nil {
+		return 0, fmt.Errorf("prFindComment: %w", err)
+	}
+
+	for c := range starlark.Elements(comments) {
+		d, ok := c.(*starlark.Dict)
+		if !ok || !prCommentOwned(d, author) {
+			continue
+		}
+
+		body, _, _ := d.Get(starlark.String("body"))
+		if s, ok := starlark.AsString(body); !ok || !strings.Contains(s, text) {
+			continue
+		}
+
+		id, _, _ := d.Get(starlark.String("id"))
+		if i, ok := id.(starlark.Int); ok {
+			if res, ok := i.Int64(); ok {
+				return res, nil
+			}
+		}
+	}
+
+	return 0, nil
+}
+
+// PRUpsertComment adds a "sticky" comment to the pull request, or updates it in place, and returns it.
+//
+// The comment is identified by the given marker that is embedded into the comment body as a hidden HTML comment.
+// Only comments created by the given author login are updated.
+// By default, it is the authenticated user, or "github-actions[bot]" for GitHub App installation tokens
+// that can't get the authenticated user; other apps should pass their bot login (like "my-app[bot]").
+// Masked values are replaced in the body.
+func (a *Action) PRUpsertComment(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var marker, body, author string
+	var number int
+	if err := starlark.UnpackArgs(
+		fn.Name(), args, kwargs,
+		"marker", &marker,
+		"body", &body,
+		"number??", &number,
+		"author??", &author,
+	); err != nil {
+		return nil, err
+	}
+
+	if marker == "" || strings.Contains(marker, "--") || strings.Contains(marker, ">") {
+		return nil, fmt.Errorf("%s: marker %q must be non-empty and must not contain \"--\" or \">\"", fn.Name(), marker)
+	}
+
+	repo, number, err := a.prPath(number)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	ctx, done := a.cancelableContext(th)
+	defer done()
+
+	hidden := "<!-- " + marker + " -->"
+
+	id, err := a.prFindComment(ctx, repo, number, hidden, author)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	method := http.MethodPost
+	p := fmt.Sprintf("%s/issues/%d/comments", repo, number)
+	if id != 0 {
+		method = http.MethodPatch
+		p = fmt.Sprintf("%s/issues/comments/%d", repo, id)
+	}
+
+	resp, err := a.apiRequest(ctx, method, p, newDict(starlark.StringDict{"body": starlark.String(hidden + "\n" + a.mask(body))}), nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	return resp.value, nil
+}
+
+// PRAddLabels adds the given labels to the pull request and returns all its labels.
+func (a *Action) PRAddLabels(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var labels starlark.Iterable
+	var number int
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "labels", &labels, "number??", &number); err != nil {
+		return nil, err
+	}
+
+	l, err := stringList(labels)
+	if err != nil {
+		return nil, fmt.Errorf("%s: labels: %w", fn.Name(), err)
+	}
+
+	repo, number, err := a.prPath(number)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	ctx, done := a.cancelableContext(th)
+	defer done()
+
+	p := fmt.Sprintf("%s/issues/%d/labels", repo, number)
+	resp, err := a.apiRequest(ctx, http.MethodPost, p, newDict(starlark.StringDict{"labels": l}), nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	return resp.value, nil
+}
+
+// PRRemoveLabels removes the given labels from the pull request.
+// Labels that are not present are ignored.
+func (a *Action) PRRemoveLabels(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var labels starlark.Iterable
+	var number int
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "labels", &labels, "number??", &number); err != nil {
+		return nil, err
+	}
+
+	l, err := stringList(labels)
+	if err != nil {
+		return nil, fmt.Errorf("%s: labels: %w", fn.Name(), err)
+	}
+
+	repo, number, err := a.prPath(number)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	ctx, done := a.cancelableContext(th)
+	defer done()
+
+	for label := range starlark.Elements(l) {
+		p := fmt.Sprintf("%s/issues/%d/labels/%s", repo, number, url.PathEscape(string(label.(starlark.String))))
+
+		_, err = a.apiRequest(ctx, http.MethodDelete, p, nil, nil)
+
+		var e *apiError
+		if errors.As(err, &e) && e.status == http.StatusNotFound {
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+		}
+	}
+
+	return starlark.None, nil
+}
+
+// PRRequestReviewers requests reviews from the given users and teams (slugs).
+func (a *Action) PRRequestReviewers(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var reviewers, teamReviewers starlark.Iterable
+	var number int
+	if err := starlark.UnpackArgs(
+		fn.Name(), args, kwargs,
+		"reviewers??", &reviewers,
+		"team_reviewers??", &teamReviewers,
+		"number??", &number,
+	); err != nil {
+		return nil, err
+	}
+
+	body := make(starlark.StringDict)
+	for name, v := range map[string]starlark.Iterable{"reviewers": reviewers, "team_reviewers": teamReviewers} {
+		if v == nil {
+			continue
+		}
+
+		l, err := stringList(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", fn.Name(), name, err)
+		}
+
+		body[name] = l
+	}
+
+	if len(body) == 0 {
+		return nil, fmt.Errorf("%s: reviewers or team_reviewers must be given", fn.Name())
+	}
+
+	repo, number, err := a.prPath(number)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	ctx, done := a.cancelableContext(th)
+	defer done()
+
+	p := fmt.Sprintf("%s/pulls/%d/requested_reviewers", repo, number)
+	resp, err := a.apiRequest(ctx, http.MethodPost, p, newDict(body), nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	return resp.value, nil
+}
+
+// PRListFiles returns a frozen list of all files changed by the pull request.
+func (a *Action) PRListFiles(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var number int
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "number??", &number); err != nil {
+		return nil, err
+	}
+
+	repo, number, err := a.prPath(number)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	ctx, done := a.cancelableContext(th)
+	defer done()
+
+	res, err := a.apiPaginate(ctx, fmt.Sprintf("%s/pulls/%d/files?per_page=100", repo, number), nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,212 @@
+package githubactions
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/AlekSi/should"
+	"github.com/AlekSi/should/must"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+// prCall calls the pr module builtin.
+func prCall(th *starlark.Thread, m *starlarkstruct.Module, name string, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	pr := m.Members["pr"].(*starlarkstruct.Module)
+	return starlark.Call(th, pr.Members[name], args, kwargs)
+}
+
+func TestPR(t *testing.T) {
+	var comments []any
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /user", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, http.StatusForbidden, map[string]any{"message": "Resource not accessible by integration"})
+	})
+	mux.HandleFunc("GET /repos/o/r/issues/7/comments", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, http.StatusOK, comments)
+	})
+	mux.HandleFunc("POST /repos/o/r/issues/7/comments", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, http.StatusCreated, map[string]any{"id": 100 + len(comments)})
+	})
+	mux.HandleFunc("PATCH /repos/o/r/issues/comments/103", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, http.StatusOK, map[string]any{"id": 103})
+	})
+	mux.HandleFunc("POST /repos/o/r/issues/7/labels", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, http.StatusOK, []any{map[string]any{"name": "bug"}, map[string]any{"name": "good first issue"}})
+	})
+	mux.HandleFunc("DELETE /repos/o/r/issues/7/labels/good%20first%20issue", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, http.StatusOK, []any{})
+	})
+	mux.HandleFunc("DELETE /repos/o/r/issues/7/labels/missing", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, http.StatusNotFound, map[string]any{"message": "Label does not exist"})
+	})
+	mux.HandleFunc("POST /repos/o/r/pulls/7/requested_reviewers", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, http.StatusCreated, map[string]any{"number": 7})
+	})
+	mux.HandleFunc("GET /repos/o/r/pulls/8/files", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, http.StatusOK, []any{map[string]any{"filename": "go.mod"}})
+	})
+
+	reqs, getenv := newAPIServer(t, mux)
+	eventPath := writeEvent(t, map[string]any{"action": "opened", "pull_request": map[string]any{"number": 7}})
+
+	var buf bytes.Buffer
+	th, m, _ := setupEnv(t, &buf, func(key string) string {
+		switch key {
+		case "GITHUB_REPOSITORY":
+			return "o/r"
+		case "GITHUB_EVENT_PATH":
+			return eventPath
+		default:
+			return getenv(key)
+		}
+	})
+
+	_, err := starlark.Call(th, m.Members["add_mask"], starlark.Tuple{starlark.String("s3cret")}, nil)
+	must.BeZero(t, err)
+
+	res, err := prCall(th, m, "comment", starlark.Tuple{starlark.String("Hello s3cret")}, nil)
+	must.BeZero(t, err)
+	should.BeEqual(t, res.String(), `{"id": 100}`)
+
+	// no comment with marker yet
+	bot := map[string]any{"login": "github-actions[bot]", "type": "Bot"}
+	comments = []any{
+		map[string]any{"id": 100, "body": "Hello", "user": bot},
+		map[string]any{"id": 99, "body": "<!-- coverage -->\nplanted", "user": map[string]any{"login": "mallory", "type": "User"}},
+		map[string]any{
+			"id":                       98,
+			"body":                     "<!-- coverage -->\nplanted by another app",
+			"user":                     map[string]any{"login": "dependabot[bot]", "type": "Bot"},
+			"performed_via_github_app": map[string]any{"slug": "dependabot"},
+		},
+	}
+	res, err = prCall(th, m, "upsert_comment", starlark.Tuple{starlark.String("coverage"), starlark.String("50%")}, nil)
+	must.BeZero(t, err)
+	should.BeEqual(t, res.String(), `{"id": 103}`)
+
+	comments = append(comments, map[string]any{"id": 103, "body": "<!-- coverage -->\n50%", "user": bot})
+	res, err = prCall(th, m, "upsert_comment", starlark.Tuple{starlark.String("coverage"), starlark.String("75% s3cret")}, nil)
+	must.BeZero(t, err)
+	should.BeEqual(t, res.String(), `{"id": 103}`)
+
+	_, err = prCall(th, m, "upsert_comment", starlark.Tuple{starlark.String("a-->b"), starlark.String("")}, nil)
+	must.NotBeZero(t, err)
+
+	labels := starlark.NewList([]starlark.Value{starlark.String("bug"), starlark.String("good first issue")})
+	res, err = prCall(th, m, "add_labels", starlark.Tuple{labels}, nil)
+	must.BeZero(t, err)
+	should.BeEqual(t, res.String(), `[{"name": "bug"}, {"name": "good first issue"}]`)
+
+	labels = starlark.NewList([]starlark.Value{starlark.String("good first issue"), starlark.String("missing")})
+	res, err = prCall(th, m, "remove_labels", starlark.Tuple{labels}, nil)
+	must.BeZero(t, err)
+	should.BeEqual(t, res, starlark.None)
+
+	_, err = prCall(th, m, "add_labels", starlark.Tuple{starlark.NewList([]starlark.Value{starlark.MakeInt(1)})}, nil)
+	must.NotBeZero(t, err)
+	should.BeEqual(t, err.Error(), "add_labels: labels: stringList: got int, want string")
+
+	kwargs := []starlark.Tuple{{starlark.String("reviewers"), starlark.NewList([]starlark.Value{starlark.String("alice")})}}
+	_, err = prCall(th, m, "request_reviewers", nil, kwargs)
+	must.BeZero(t, err)
+
+	_, err = prCall(th, m, "request_reviewers", nil, nil)
+	must.NotBeZero(t, err)
+	should.BeEqual(t, err.Error(), "request_reviewers: reviewers or team_reviewers must be given")
+
+	res, err = prCall(th, m, "list_files", nil, []starlark.Tuple{{starlark.String("number"), starlark.MakeInt(8)}})
+	must.BeZero(t, err)
+	should.BeEqual(t, res.String(), `[{"filename": "go.mod"}]`)
+
+	expected := []apiRequestLog{
+		{Method: "POST", Path: "/repos/o/r/issues/7/comments", Authorization: "Bearer ghs_test", Body: `{"body":"Hello ***"}`},
+		{Method: "GET", Path: "/user", Authorization: "Bearer ghs_test"},
+		{Method: "GET", Path: "/repos/o/r/issues/7/comments?per_page=100", Authorization: "Bearer ghs_test"},
+		{Method: "POST", Path: "/repos/o/r/issues/7/comments", Authorization: "Bearer ghs_test", Body: `{"body":"<!-- coverage -->\n50%"}`},
+		{Method: "GET", Path: "/user", Authorization: "Bearer ghs_test"},
+		{Method: "GET", Path: "/repos/o/r/issues/7/comments?per_page=100", Authorization: "Bearer ghs_test"},
+		{Method: "PATCH", Path: "/repos/o/r/issues/comments/103", Authorization: "Bearer ghs_test", Body: `{"body":"<!-- coverage -->\n75% ***"}`},
+		{Method: "POST", Path: "/repos/o/r/issues/7/labels", Authorization: "Bearer ghs_test", Body: `{"labels":["bug","good first issue"]}`},
+		{Method: "DELETE", Path: "/repos/o/r/issues/7/labels/good%20first%20issue", Authorization: "Bearer ghs_test"},
+		{Method: "DELETE", Path: "/repos/o/r/issues/7/labels/missing", Authorization: "Bearer ghs_test"},
+		{Method: "POST", Path: "/repos/o/r/pulls/7/requested_reviewers", Authorization: "Bearer ghs_test", Body: `{"reviewers":["alice"]}`},
+		{Method: "GET", Path: "/repos/o/r/pulls/8/files?per_page=100", Authorization: "Bearer ghs_test"},
+	}
+	should.BeEqual(t, *reqs, expected)
+
+	should.BeEqual(t, buf.String(), "::add-mask::s3cret\n")
+}
+
+func TestPRUpsertCommentUser(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /user", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, http.StatusOK, map[string]any{"login": "alice"})
+	})
+	mux.HandleFunc("GET /repos/o/r/issues/7/comments", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, http.StatusOK, []any{
+			map[string]any{"id": 100, "body": "<!-- coverage -->", "user": map[string]any{"login": "mallory", "type": "User"}},
+			map[string]any{"id": 101, "body": "<!-- coverage -->", "user": map[string]any{"login": "some-bot[bot]", "type": "Bot"}},
+			map[string]any{"id": 102, "body": "<!-- coverage -->", "user": map[string]any{"login": "alice", "type": "User"}},
+		})
+	})
+	mux.HandleFunc("PATCH /repos/o/r/issues/comments/101", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, http.StatusOK, map[string]any{"id": 101})
+	})
+	mux.HandleFunc("PATCH /repos/o/r/issues/comments/102", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, http.StatusOK, map[string]any{"id": 102})
+	})
+
+	reqs, getenv := newAPIServer(t, mux)
+
+	var buf bytes.Buffer
+	th, m, _ := setupEnv(t, &buf, func(key string) string {
+		if key == "GITHUB_REPOSITORY" {
+			return "o/r"
+		}
+		return getenv(key)
+	})
+
+	kwargs := []starlark.Tuple{{starlark.String("number"), starlark.MakeInt(7)}}
+	res, err := prCall(th, m, "upsert_comment", starlark.Tuple{starlark.String("coverage"), starlark.String("75%")}, kwargs)
+	must.BeZero(t, err)
+	should.BeEqual(t, res.String(), `{"id": 102}`)
+
+	// explicit author
+	kwargs = append(kwargs, starlark.Tuple{starlark.String("author"), starlark.String("some-bot[bot]")})
+	res, err = prCall(th, m, "upsert_comment", starlark.Tuple{starlark.String("coverage"), starlark.String("80%")}, kwargs)
+	must.BeZero(t, err)
+	should.BeEqual(t, res.String(), `{"id": 101}`)
+
+	expected := []apiRequestLog{
+		{Method: "GET", Path: "/user", Authorization: "Bearer ghs_test"},
+		{Method: "GET", Path: "/repos/o/r/issues/7/comments?per_page=100", Authorization: "Bearer ghs_test"},
+		{Method: "PATCH", Path: "/repos/o/r/issues/comments/102", Authorization: "Bearer ghs_test", Body: `{"body":"<!-- coverage -->\n75%"}`},
+		{Method: "GET", Path: "/repos/o/r/issues/7/comments?per_page=100", Authorization: "Bearer ghs_test"},
+		{Method: "PATCH", Path: "/repos/o/r/issues/comments/101", Authorization: "Bearer ghs_test", Body: `{"body":"<!-- coverage -->\n80%"}`},
+	}
+	should.BeEqual(t, *reqs, expected)
+}
+
+func TestPRNoNumber(t *testing.T) {
+	eventPath := writeEvent(t, map[string]any{"ref": "refs/heads/main"})
+
+	var buf bytes.Buffer
+	th, m, _ := setupEnv(t, &buf, func(key string) string {
+		switch key {
+		case "GITHUB_REPOSITORY":
+			return "o/r"
+		case "GITHUB_EVENT_NAME":
+			return "push"
+		case "GITHUB_EVENT_PATH":
+			return eventPath
+		default:
+			return ""
+		}
+	})
+
+	_, err := prCall(th, m, "comment", starlark.Tuple{starlark.String("Hello")}, nil)
+	must.NotBeZero(t, err)
+	should.BeEqual(t, err.Error(), `comment: prPath: prNumber: no pull request number in the "push" event payload`)
+}
@@ -0,0 +1,71 @@
+package githubactions
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"go.starlark.net/starlark"
+)
+
+// commitStates are valid commit status states.
+var commitStates = []string{"error", "failure", "pending", "success"}
+
+// SetCommitStatus creates a commit status for the sha (GITHUB_SHA by default) and returns it.
+// Masked values are replaced in the description.
+// See https://docs.github.com/en/rest/commits/statuses#create-a-commit-status.
+func (a *Action) SetCommitStatus(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var state, context, description, targetURL, sha string
+	if err := starlark.UnpackArgs(
+		fn.Name(), args, kwargs,
+		"state", &state,
+		"context", &context,
+		"description??", &description,
+		"target_url??", &targetURL,
+		"sha??", &sha,
+	); err != nil {
+		return nil, err
+	}
+
+	if !slices.Contains(commitStates, state) {
+		return nil, fmt.Errorf("%s: invalid state %q, must be one of %v", fn.Name(), state, commitStates)
+	}
+
+	if context == "" {
+		return nil, fmt.Errorf("%s: context must not be empty", fn.Name())
+	}
+
+	if sha == "" {
+		sha = a.a.Getenv("GITHUB_SHA")
+	}
+
+	if sha == "" {
+		return nil, fmt.Errorf("%s: sha is not given, and GITHUB_SHA is not set", fn.Name())
+	}
+
+	repo, err := a.repoPath()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	body := starlark.StringDict{
+		"state":   starlark.String(state),
+		"context": starlark.String(context),
+	}
+	if description != "" {
+		body["description"] = starlark.String(a.mask(description))
+	}
+	if targetURL != "" {
+		body["target_url"] = starlark.String(targetURL)
+	}
+
+	ctx, done := a.cancelableContext(th)
+	defer done()
+
+	resp, err := a.apiRequest(ctx, http.MethodPost, repo+"/statuses/"+sha, newDict(body), nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	return resp.value, nil
+}
@@ -0,0 +1,69 @@
+package githubactions
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/AlekSi/should"
+	"github.com/AlekSi/should/must"
+	"go.starlark.net/starlark"
+)
+
+func TestSetCommitStatus(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /repos/o/r/statuses/{sha}", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, http.StatusCreated, map[string]any{"id": 1, "state": "pending"})
+	})
+
+	reqs, getenv := newAPIServer(t, mux)
+
+	var buf bytes.Buffer
+	th, m, _ := setupEnv(t, &buf, func(key string) string {
+		switch key {
+		case "GITHUB_REPOSITORY":
+			return "o/r"
+		case "GITHUB_SHA":
+			return "abc"
+		default:
+			return getenv(key)
+		}
+	})
+
+	_, err := starlark.Call(th, m.Members["add_mask"], starlark.Tuple{starlark.String("s3cret")}, nil)
+	must.BeZero(t, err)
+
+	args := starlark.Tuple{starlark.String("pending"), starlark.String("ci/deploy")}
+	res, err := starlark.Call(th, m.Members["set_commit_status"], args, nil)
+	must.BeZero(t, err)
+	should.BeEqual(t, res.String(), `{"id": 1, "state": "pending"}`)
+
+	kwargs := []starlark.Tuple{
+		{starlark.String("description"), starlark.String("Deployed with s3cret")},
+		{starlark.String("target_url"), starlark.String("https://example.com/")},
+		{starlark.String("sha"), starlark.String("def")},
+	}
+	args = starlark.Tuple{starlark.String("success"), starlark.String("ci/deploy")}
+	_, err = starlark.Call(th, m.Members["set_commit_status"], args, kwargs)
+	must.BeZero(t, err)
+
+	args = starlark.Tuple{starlark.String("ok"), starlark.String("ci/deploy")}
+	_, err = starlark.Call(th, m.Members["set_commit_status"], args, nil)
+	must.NotBeZero(t, err)
+	should.BeEqual(t, err.Error(), `set_commit_status: invalid state "ok", must be one of [error failure pending success]`)
+
+	expected := []apiRequestLog{{
+		Method:        "POST",
+		Path:          "/repos/o/r/statuses/abc",
+		Authorization: "Bearer ghs_test",
+		Body:          `{"context":"ci/deploy","state":"pending"}`,
+	}, {
+		Method:        "POST",
+		Path:          "/repos/o/r/statuses/def",
+		Authorization: "Bearer ghs_test",
+		Body:          `{"context":"ci/deploy","description":"Deployed with ***","state":"success","target_url":"https://example.com/"}`,
+	}}
+	should.BeEqual(t, *reqs, expected)
+
+	should.BeEqual(t, buf.String(), "::add-mask::s3cret\n")
+}
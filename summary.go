@@ -0,0 +1,487 @@
+package githubactions
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+// summaryBuffer buffers job summary content until it is written.
+type summaryBuffer struct {
+	m   sync.Mutex
+	buf strings.Builder
+}
+
+// newSummaryModule constructs the summary Starlark module for the given [Action].
+//
+// Builtins that add content return the module itself, so calls can be chained:
+//
+//	summary.heading("Results").table(rows, header = ["Name", "Result"]).write()
+func newSummaryModule(a *Action) *starlarkstruct.Module {
+	m := &starlarkstruct.Module{
+		Name:    "summary",
+		Members: make(starlark.StringDict),
+	}
+
+	for _, b := range []*starlark.Builtin{
+		starlark.NewBuiltin("heading", a.SummaryHeading),
+		starlark.NewBuiltin("table", a.SummaryTable),
+		starlark.NewBuiltin("code_block", a.SummaryCodeBlock),
+		starlark.NewBuiltin("list", a.SummaryList),
+		starlark.NewBuiltin("details", a.SummaryDetails),
+		starlark.NewBuiltin("link", a.SummaryLink),
+		starlark.NewBuiltin("image", a.SummaryImage),
+		starlark.NewBuiltin("separator", a.SummarySeparator),
+		starlark.NewBuiltin("line_break", a.SummaryLineBreak),
+		starlark.NewBuiltin("quote", a.SummaryQuote),
+		starlark.NewBuiltin("raw", a.SummaryRaw),
+		starlark.NewBuiltin("eol", a.SummaryEOL),
+
+		starlark.NewBuiltin("stringify", a.SummaryStringify),
+		starlark.NewBuiltin("is_empty", a.SummaryIsEmpty),
+		starlark.NewBuiltin("write", a.SummaryWrite),
+		starlark.NewBuiltin("clear", a.SummaryClear),
+	} {
+		m.Members[b.Name()] = b.BindReceiver(m)
+	}
+
+	return m
+}
+
+// htmlElement returns an HTML element with the given tag, escaped attributes, and raw content.
+// If content is nil, the element has no closing tag.
+// Attributes with empty values are skipped.
+func htmlElement(tag string, content *string, attrs ...string) string {
+	var res strings.Builder
+	res.WriteString("<" + tag)
+
+	for i := 0; i < len(attrs); i += 2 {
+		if attrs[i+1] == "" {
+			continue
+		}
+
+		res.WriteString(" " + attrs[i] + `="` + html.EscapeString(attrs[i+1]) + `"`)
+	}
+
+	res.WriteString(">")
+
+	if content != nil {
+		res.WriteString(*content + "</" + tag + ">")
+	}
+
+	return res.String()
+}
+
+// escaped returns a pointer to the HTML-escaped text for [htmlElement].
+func escaped(text string) *string {
+	res := html.EscapeString(text)
+	return &res
+}
+
+// raw returns a pointer to the raw text for [htmlElement].
+func raw(text string) *string {
+	return &text
+}
+
+// summaryAdd adds the given raw content to the summary buffer, and returns the receiver of fn.
+func (a *Action) summaryAdd(fn *starlark.Builtin, content string) starlark.Value {
+	a.summary.m.Lock()
+	a.summary.buf.WriteString(content)
+	a.summary.m.Unlock()
+
+	if recv := fn.Receiver(); recv != nil {
+		return recv
+	}
+
+	return starlark.None
+}
+
+// SummaryHeading adds a heading with the given level (1 to 6) to the job summary buffer.
+func (a *Action) SummaryHeading(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var text string
+	level := 1
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "text", &text, "level?", &level); err != nil {
+		return nil, err
+	}
+
+	if level < 1 || level > 6 {
+		return nil, fmt.Errorf("%s: level must be between 1 and 6, got %d", fn.Name(), level)
+	}
+
+	return a.summaryAdd(fn, htmlElement(fmt.Sprintf("h%d", level), escaped(text))+"\n"), nil
+}
+
+// summaryCell renders a table cell.
+//
+// The cell is either a value (converted to string) or a dict with "data" key
+// and optional "header" (bool), "colspan", and "rowspan" keys.
+func summaryCell(v starlark.Value, header bool) (string, error) {
+	d, ok := v.(*starlark.Dict)
+	if !ok {
+		s, ok := starlark.AsString(v)
+		if !ok {
+			s = v.String()
+		}
+
+		tag := "td"
+		if header {
+			tag = "th"
+		}
+
+		return htmlElement(tag, escaped(s)), nil
+	}
+
+	var data, colspan, rowspan string
+	for _, item := range d.Items() {
+		k, _ := starlark.AsString(item[0])
+		switch k {
+		case "data":
+			s, ok := starlark.AsString(item[1])
+			if !ok {
+				s = item[1].String()
+			}
+			data = s
+		case "header":
+			header = bool(item[1].Truth())
+		case "colspan", "rowspan":
+			span, err := cellSpan(item[1])
+			if err != nil {
+				return "", fmt.Errorf("%s: %w", k, err)
+			}
+
+			if k == "colspan" {
+				colspan = span
+			} else {
+				rowspan = span
+			}
+		default:
+			return "", fmt.Errorf("unexpected cell key %s", item[0])
+		}
+	}
+
+	tag := "td"
+	if header {
+		tag = "th"
+	}
+
+	return htmlElement(tag, escaped(data), "colspan", colspan, "rowspan", rowspan), nil
+}
+
+// cellSpan returns the colspan or rowspan attribute value
+// given as a positive integer or a string containing one.
+func cellSpan(v starlark.Value) (string, error) {
+	if s, ok := starlark.AsString(v); ok {
+		i, err := strconv.ParseInt(s, 10, 32)
+		if err != nil || i <= 0 {
+			return "", fmt.Errorf("got %q, want positive integer", s)
+		}
+
+		return strconv.FormatInt(i, 10), nil
+	}
+
+	i, err := starlark.AsInt32(v)
+	if err != nil {
+		return "", fmt.Errorf("got %s, want positive integer", v.Type())
+	}
+
+	if i <= 0 {
+		return "", fmt.Errorf("got %d, want positive integer", i)
+	}
+
+	return strconv.Itoa(i), nil
+}
+
+// summaryRow renders a table row.
+func summaryRow(row starlark.Value, header bool) (string, error) {
+	iter, ok := row.(starlark.Iterable)
+	if !ok {
+		return "", fmt.Errorf("row is %s, want list", row.Type())
+	}
+
+	var cells strings.Builder
+
+	it := iter.Iterate()
+	defer it.Done()
+
+	var cell starlark.Value
+	for it.Next(&cell) {
+		s, err := summaryCell(cell, header)
+		if err != nil {
+			return "", err
+		}
+
+		cells.WriteString(s)
+	}
+
+	return htmlElement("tr", raw(cells.String())), nil
+}
+
+// SummaryTable adds a table to the job summary buffer.
+//
+// Rows are lists of cells; the optional header is a list of header cells.
+// Each cell is either a value (converted to string) or a dict with "data" key
+// and optional "header" (bool), "colspan", and "rowspan" keys.
+func (a *Action) SummaryTable(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var rows starlark.Iterable
+	var header starlark.Value
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "rows", &rows, "header??", &header); err != nil {
+		return nil, err
+	}
+
+	var content strings.Builder
+
+	if header != nil {
+		s, err := summaryRow(header, true)
+		if err != nil {
+			return nil, fmt.Errorf("%s: header: %w", fn.Name(), err)
+		}
+
+		content.WriteString(s)
+	}
+
+	it := rows.Iterate()
+	defer it.Done()
+
+	var row starlark.Value
+	for i := 0; it.Next(&row); i++ {
+		s, err := summaryRow(row, false)
+		if err != nil {
+			return nil, fmt.Errorf("%s: row %d: %w", fn.Name(), i, err)
+		}
+
+		content.WriteString(s)
+	}
+
+	return a.summaryAdd(fn, htmlElement("table", raw(content.String()))+"\n"), nil
+}
+
+// SummaryCodeBlock adds a code block with optional language to the job summary buffer.
+func (a *Action) SummaryCodeBlock(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var code, lang string
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "code", &code, "lang??", &lang); err != nil {
+		return nil, err
+	}
+
+	return a.summaryAdd(fn, htmlElement("pre", raw(htmlElement("code", escaped(code))), "lang", lang)+"\n"), nil
+}
+
+// SummaryList adds a bullet or (if ordered is true) ordered list to the job summary buffer.
+func (a *Action) SummaryList(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var items starlark.Iterable
+	var ordered bool
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "items", &items, "ordered?", &ordered); err != nil {
+		return nil, err
+	}
+
+	var content strings.Builder
+
+	it := items.Iterate()
+	defer it.Done()
+
+	var item starlark.Value
+	for it.Next(&item) {
+		s, ok := starlark.AsString(item)
+		if !ok {
+			s = item.String()
+		}
+
+		content.WriteString(htmlElement("li", escaped(s)))
+	}
+
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+	}
+
+	return a.summaryAdd(fn, htmlElement(tag, raw(content.String()))+"\n"), nil
+}
+
+// SummaryDetails adds a collapsible details element to the job summary buffer.
+func (a *Action) SummaryDetails(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var label, content string
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "label", &label, "content", &content); err != nil {
+		return nil, err
+	}
+
+	return a.summaryAdd(fn, htmlElement("details", raw(htmlElement("summary", escaped(label))+html.EscapeString(content)))+"\n"), nil
+}
+
+// SummaryLink adds a link to the job summary buffer.
+func (a *Action) SummaryLink(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var text, href string
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "text", &text, "href", &href); err != nil {
+		return nil, err
+	}
+
+	return a.summaryAdd(fn, htmlElement("a", escaped(text), "href", href)+"\n"), nil
+}
+
+// SummaryImage adds an image with optional width and height to the job summary buffer.
+func (a *Action) SummaryImage(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var src, alt string
+	var width, height int
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "src", &src, "alt", &alt, "width??", &width, "height??", &height); err != nil {
+		return nil, err
+	}
+
+	var w, h string
+	if width > 0 {
+		w = fmt.Sprint(width)
+	}
+	if height > 0 {
+		h = fmt.Sprint(height)
+	}
+
+	return a.summaryAdd(fn, htmlElement("img", nil, "src", src, "alt", alt, "width", w, "height", h)+"\n"), nil
+}
+
+// SummarySeparator adds a horizontal rule to the job summary buffer.
+func (a *Action) SummarySeparator(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs); err != nil {
+		return nil, err
+	}
+
+	return a.summaryAdd(fn, htmlElement("hr", nil)+"\n"), nil
+}
+
+// SummaryLineBreak adds a line break to the job summary buffer.
+func (a *Action) SummaryLineBreak(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs); err != nil {
+		return nil, err
+	}
+
+	return a.summaryAdd(fn, htmlElement("br", nil)+"\n"), nil
+}
+
+// SummaryQuote adds a quote with optional citation URL to the job summary buffer.
+func (a *Action) SummaryQuote(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var text, cite string
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "text", &text, "cite??", &cite); err != nil {
+		return nil, err
+	}
+
+	return a.summaryAdd(fn, htmlElement("blockquote", escaped(text), "cite", cite)+"\n"), nil
+}
+
+// SummaryRaw adds raw Markdown or HTML without escaping to the job summary buffer.
+// If add_eol is true, a newline is added.
+func (a *Action) SummaryRaw(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var text string
+	var addEOL bool
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "text", &text, "add_eol?", &addEOL); err != nil {
+		return nil, err
+	}
+
+	if addEOL {
+		text += "\n"
+	}
+
+	return a.summaryAdd(fn, text), nil
+}
+
+// SummaryEOL adds a newline to the job summary buffer.
+func (a *Action) SummaryEOL(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs); err != nil {
+		return nil, err
+	}
+
+	return a.summaryAdd(fn, "\n"), nil
+}
+
+// SummaryStringify returns the job summary buffer content.
+func (a *Action) SummaryStringify(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs); err != nil {
+		return nil, err
+	}
+
+	a.summary.m.Lock()
+	defer a.summary.m.Unlock()
+
+	return starlark.String(a.summary.buf.String()), nil
+}
+
+// SummaryIsEmpty returns true if the job summary buffer is empty.
+func (a *Action) SummaryIsEmpty(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs); err != nil {
+		return nil, err
+	}
+
+	a.summary.m.Lock()
+	defer a.summary.m.Unlock()
+
+	return starlark.Bool(a.summary.buf.Len() == 0), nil
+}
+
+// writeSummary writes the given content to the job summary file, and empties the buffer.
+// If overwrite is true, the file is truncated first.
+func (a *Action) writeSummary(content string, overwrite bool) error {
+	path := a.a.Getenv("GITHUB_STEP_SUMMARY")
+	if path == "" {
+		return fmt.Errorf("writeSummary: GITHUB_STEP_SUMMARY is not set")
+	}
+
+	flag := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	if overwrite {
+		flag = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+
+	f, err := os.OpenFile(path, flag, 0o644)
+	if err != nil {
+		return fmt.Errorf("writeSummary: %w", err)
+	}
+
+	if _, err = f.WriteString(a.mask(content)); err != nil {
+		f.Close()
+		return fmt.Errorf("writeSummary: %w", err)
+	}
+
+	if err = f.Close(); err != nil {
+		return fmt.Errorf("writeSummary: %w", err)
+	}
+
+	return nil
+}
+
+// SummaryWrite writes the job summary buffer to the job summary file, and empties the buffer.
+// If overwrite is true, the existing job summary of this step is replaced.
+// See https://docs.github.com/en/actions/reference/workflows-and-actions/workflow-commands#adding-a-job-summary.
+func (a *Action) SummaryWrite(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var overwrite bool
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "overwrite?", &overwrite); err != nil {
+		return nil, err
+	}
+
+	a.summary.m.Lock()
+	defer a.summary.m.Unlock()
+
+	if err := a.writeSummary(a.summary.buf.String(), overwrite); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	a.summary.buf.Reset()
+	return starlark.None, nil
+}
+
+// SummaryClear empties the job summary buffer and the job summary of this step.
+func (a *Action) SummaryClear(th *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs); err != nil {
+		return nil, err
+	}
+
+	a.summary.m.Lock()
+	defer a.summary.m.Unlock()
+
+	a.summary.buf.Reset()
+
+	if err := a.writeSummary("", true); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+
+	return starlark.None, nil
+}
@@ -0,0 +1,136 @@
+package githubactions
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AlekSi/should"
+	"github.com/AlekSi/should/must"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// checkGolden compares actual content with the golden file in testdata.
+func checkGolden(tb testing.TB, name, actual string) {
+	tb.Helper()
+
+	path := filepath.Join("testdata", name)
+
+	if *update {
+		must.BeZero(tb, os.WriteFile(path, []byte(actual), 0o666))
+	}
+
+	b, err := os.ReadFile(path)
+	must.BeZero(tb, err)
+	should.BeEqual(tb, actual, string(b))
+}
+
+func TestSummary(t *testing.T) {
+	var buf bytes.Buffer
+	th, m, getenv := setup(t, &buf, nil)
+
+	script := `
+summary.heading("Test <results>")
+summary.heading("Details & more", level = 3).eol()
+
+summary.table(
+    [
+        ["TestFoo", "ok", 1.5],
+        [{"data": "TestBar", "rowspan": 2}, "FAIL", 0.25],
+        [{"data": "x | y", "colspan": 2}],
+    ],
+    header = ["Name", "Result", {"data": "Time, s", "header": True}],
+)
+
+summary.code_block("if a < b && c {\n\treturn\n}", lang = "go").code_block("plain")
+summary.list(["one", "<two>"]).list([1, 2], ordered = True)
+summary.details("Logs", "line 1\nline 2")
+summary.link("Run \"42\"", "https://github.com/owner/repo/actions/runs/42?a=1&b=2")
+summary.image("https://example.com/chart.png", "Chart", width = 100)
+summary.separator().line_break()
+summary.quote("To be or not to be", cite = "https://example.com/hamlet")
+summary.raw("**raw markdown**", add_eol = True)
+
+empty_before = summary.is_empty()
+summary.write()
+empty_after = summary.is_empty()
+`
+	globals, err := starlark.ExecFileOptions(&syntax.FileOptions{}, th, t.Name(), script, m.Members)
+	must.BeZero(t, err)
+	should.BeEqual(t, globals["empty_before"], starlark.False)
+	should.BeEqual(t, globals["empty_after"], starlark.True)
+	should.BeEqual(t, buf.String(), "")
+
+	b, err := os.ReadFile(getenv("GITHUB_STEP_SUMMARY"))
+	must.BeZero(t, err)
+	checkGolden(t, "summary.golden.md", string(b))
+}
+
+func TestSummaryWriteClear(t *testing.T) {
+	var buf bytes.Buffer
+	th, m, getenv := setup(t, &buf, nil)
+
+	script := `
+summary.raw("first\n").write()
+summary.raw("second\n").write()
+result = summary.raw("third\n").stringify()
+`
+	globals, err := starlark.ExecFileOptions(&syntax.FileOptions{}, th, t.Name(), script, m.Members)
+	must.BeZero(t, err)
+	should.BeEqual(t, globals["result"], starlark.String("third\n"))
+
+	b, err := os.ReadFile(getenv("GITHUB_STEP_SUMMARY"))
+	must.BeZero(t, err)
+	should.BeEqual(t, string(b), "first\nsecond\n")
+
+	_, err = starlark.ExecFileOptions(&syntax.FileOptions{}, th, t.Name(), `summary.write(overwrite = True)`, m.Members)
+	must.BeZero(t, err)
+
+	b, err = os.ReadFile(getenv("GITHUB_STEP_SUMMARY"))
+	must.BeZero(t, err)
+	should.BeEqual(t, string(b), "third\n")
+
+	_, err = starlark.ExecFileOptions(&syntax.FileOptions{}, th, t.Name(), `summary.raw("fourth").clear()`, m.Members)
+	must.BeZero(t, err)
+
+	b, err = os.ReadFile(getenv("GITHUB_STEP_SUMMARY"))
+	must.BeZero(t, err)
+	should.BeEqual(t, string(b), "")
+
+	_, err = starlark.ExecFileOptions(&syntax.FileOptions{}, th, t.Name(), `summary.heading("x", level = 7)`, m.Members)
+	must.NotBeZero(t, err)
+	should.BeEqual(t, err.Error(), "heading: level must be between 1 and 6, got 7")
+}
+
+func TestSummaryTableSpan(t *testing.T) {
+	var buf bytes.Buffer
+	th, m, _ := setup(t, &buf, nil)
+
+	for expr, expected := range map[string]string{
+		`{"data": "x", "colspan": "2"}`:   "<table><tr><td colspan=\"2\">x</td></tr></table>\n",
+		`{"data": "x", "rowspan": 3}`:     "<table><tr><td rowspan=\"3\">x</td></tr></table>\n",
+		`{"data": "x", "colspan": 0}`:     "table: row 0: colspan: got 0, want positive integer",
+		`{"data": "x", "rowspan": "-1"}`:  `table: row 0: rowspan: got "-1", want positive integer`,
+		`{"data": "x", "colspan": "2\""}`: `table: row 0: colspan: got "2\"", want positive integer`,
+		`{"data": "x", "colspan": 1.5}`:   "table: row 0: colspan: got float, want positive integer",
+	} {
+		t.Run(expr, func(t *testing.T) {
+			script := "result = summary.table([[" + expr + "]]).stringify()\nsummary.clear()\n"
+			globals, err := starlark.ExecFileOptions(&syntax.FileOptions{}, th, t.Name(), script, m.Members)
+			if strings.HasPrefix(expected, "<") {
+				must.BeZero(t, err)
+				should.BeEqual(t, globals["result"], starlark.String(expected))
+				return
+			}
+
+			must.NotBeZero(t, err)
+			should.BeEqual(t, err.Error(), expected)
+		})
+	}
+}